@@ -9,7 +9,9 @@ import (
 	"strings"
 	"syscall"
 
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/approval"
 	"github.com/contaimlabs/external-dns-bunny-webhook/internal/bunny"
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/drift"
 	"github.com/contaimlabs/external-dns-bunny-webhook/internal/health"
 	"github.com/contaimlabs/external-dns-bunny-webhook/internal/webhook"
 	"github.com/hashicorp/go-cleanhttp"
@@ -22,11 +24,13 @@ const (
 )
 
 type Options struct {
-	LogFormat string          `env:"LOG_FORMAT, default=text"`
-	LogLevel  string          `env:"LOG_LEVEL, default=info"`
-	Bunny     bunny.Options   `env:", prefix=BUNNY_"`
-	Health    health.Options  `env:", prefix=HEALTH_"`
-	Webhook   webhook.Options `env:", prefix=WEBHOOK_"`
+	LogFormat string           `env:"LOG_FORMAT, default=text"`
+	LogLevel  string           `env:"LOG_LEVEL, default=info"`
+	Approval  approval.Options `env:", prefix=APPROVAL_"`
+	Bunny     bunny.Options    `env:", prefix=BUNNY_"`
+	Drift     drift.Options    `env:", prefix=DRIFT_"`
+	Health    health.Options   `env:", prefix=HEALTH_"`
+	Webhook   webhook.Options  `env:", prefix=WEBHOOK_"`
 }
 
 func main() {
@@ -39,20 +43,52 @@ func main() {
 	log := createLogger(opts)
 	slog.SetDefault(log)
 
+	if len(os.Args) > 1 && os.Args[1] == "restore" {
+		if err := runRestore(ctx, opts, os.Args[2:]); err != nil {
+			slog.Error("Failed to restore snapshot.", slog.Any("error", err))
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	sup := suture.NewSimple(serviceName)
 
-	health := &health.Server{Options: opts.Health}
+	provider, err := bunny.NewProvider(bunny.NewDNSClient(cleanhttp.DefaultPooledClient(), opts.Bunny.APIKey), opts.Bunny)
+	if err != nil {
+		slog.Error("Failed to create provider.", slog.Any("error", err))
+		os.Exit(1)
+	}
+
+	health := &health.Server{
+		Options:      opts.Health,
+		DegradedFunc: provider.Degraded,
+	}
 	sup.Add(health)
 
 	sup.Add(&webhook.Server{
 		Options:     opts.Webhook,
-		Provider:    bunny.NewProvider(bunny.NewDNSClient(cleanhttp.DefaultPooledClient(), opts.Bunny.APIKey), opts.Bunny),
+		Provider:    provider,
 		HealthyFunc: health.SetHealthy,
 	})
 
+	if opts.Drift.Enabled {
+		sup.Add(&drift.Detector{
+			Options: opts.Drift,
+			Checker: provider,
+		})
+	}
+
+	if provider.ApprovalsEnabled() {
+		sup.Add(&approval.Server{
+			Options: opts.Approval,
+			Queue:   provider,
+		})
+	}
+
 	slog.InfoContext(ctx, "Starting external-dns-bunny-webhook")
 
-	err := sup.Serve(ctx)
+	err = sup.Serve(ctx)
 	switch {
 	case errors.Is(err, context.Canceled):
 		slog.Info("Shutdown complete.")
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/bunny"
+	"github.com/hashicorp/go-cleanhttp"
+)
+
+// runRestore implements the restore subcommand, which reverts the zones in a
+// snapshot written by the provider to the state they were in when the
+// snapshot was taken.
+func runRestore(ctx context.Context, opts Options, args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	dryRun := fs.Bool("dry-run", false, "only log the changes that would be made")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "Usage: %s restore [-dry-run] <snapshot>\n", serviceName)
+		fs.PrintDefaults()
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one snapshot must be given")
+	}
+
+	snapshot, err := bunny.ReadSnapshot(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+
+	opts.Bunny.DryRun = opts.Bunny.DryRun || *dryRun
+
+	provider, err := bunny.NewProvider(bunny.NewDNSClient(cleanhttp.DefaultPooledClient(), opts.Bunny.APIKey), opts.Bunny)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Restoring snapshot.",
+		slog.String("snapshot", snapshot.String()),
+		slog.Bool("dry_run", opts.Bunny.DryRun))
+
+	if err := provider.RestoreSnapshot(ctx, snapshot); err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "Snapshot restored.")
+
+	return nil
+}
@@ -6,11 +6,13 @@ toolchain go1.23.4
 
 require (
 	github.com/hashicorp/go-cleanhttp v0.5.2
+	github.com/prometheus/client_golang v1.20.5
 	github.com/puzpuzpuz/xsync/v3 v3.5.0
 	github.com/samber/lo v1.49.1
 	github.com/samber/oops v1.15.0
 	github.com/sethvargo/go-envconfig v1.1.0
 	github.com/thejerf/suture/v4 v4.0.6
+	golang.org/x/sync v0.10.0
 	sigs.k8s.io/external-dns v0.15.1
 )
 
@@ -25,11 +27,11 @@ require (
 	github.com/google/go-cmp v0.6.0 // indirect
 	github.com/google/gofuzz v1.2.0 // indirect
 	github.com/json-iterator/go v1.1.12 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
 	github.com/modern-go/concurrent v0.0.0-20180306012644-bacd9c7ef1dd // indirect
 	github.com/modern-go/reflect2 v1.0.2 // indirect
 	github.com/munnerz/goautoneg v0.0.0-20191010083416-a7dc8b61c822 // indirect
 	github.com/oklog/ulid/v2 v2.1.0 // indirect
-	github.com/prometheus/client_golang v1.20.5 // indirect
 	github.com/prometheus/client_model v0.6.1 // indirect
 	github.com/prometheus/common v0.55.0 // indirect
 	github.com/prometheus/procfs v0.15.1 // indirect
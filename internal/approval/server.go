@@ -0,0 +1,173 @@
+package approval
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"log/slog"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/auth"
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/bunny"
+)
+
+type Options struct {
+	Host         string        `env:"HOST, default=localhost"`
+	Port         string        `env:"PORT, default=8081"`
+	ReadTimeout  time.Duration `env:"READ_TIMEOUT, default=60s"`
+	WriteTimeout time.Duration `env:"WRITE_TIMEOUT, default=60s"`
+
+	AuthToken     string `env:"AUTH_TOKEN"`
+	AuthTokenFile string `env:"AUTH_TOKEN_FILE"`
+}
+
+func (o *Options) Addr() string {
+	return fmt.Sprintf("%s:%s", o.Host, o.Port)
+}
+
+// Queue is the approval queue operated through the server.
+type Queue interface {
+	PendingChanges() []bunny.PendingChange
+	ApproveChange(ctx context.Context, id string) error
+	RejectChange(ctx context.Context, id string) error
+}
+
+// Server exposes the approval queue over HTTP, so operators can list, approve
+// and reject parked changes.
+type Server struct {
+	Options Options
+	Queue   Queue
+}
+
+func (s *Server) Serve(ctx context.Context) error {
+	if s.Queue == nil {
+		return fmt.Errorf("queue is required")
+	}
+
+	token, err := auth.Token(s.Options.AuthToken, s.Options.AuthTokenFile)
+	if err != nil {
+		return err
+	}
+
+	// Approving a change applies it to the zone, so the API is only served
+	// without authentication to clients on the same host.
+	if token == "" && !isLoopback(s.Options.Host) {
+		return fmt.Errorf("the approval API requires an auth token when listening on %q", s.Options.Host)
+	}
+
+	m := http.NewServeMux()
+	m.HandleFunc("GET /changes", s.handleList)
+	m.HandleFunc("POST /changes/{id}/approve", s.handleApprove)
+	m.HandleFunc("POST /changes/{id}/reject", s.handleReject)
+
+	var handler http.Handler = m
+	if token != "" {
+		handler = requireToken(token, m)
+	}
+
+	srv := &http.Server{
+		Addr:         s.Options.Addr(),
+		Handler:      handler,
+		ReadTimeout:  s.Options.ReadTimeout,
+		WriteTimeout: s.Options.WriteTimeout,
+	}
+
+	l, err := net.Listen("tcp", s.Options.Addr())
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	go func() {
+		<-ctx.Done()
+
+		if err := srv.Shutdown(ctx); err != nil {
+			log.Fatal(err)
+		}
+
+		l.Close()
+	}()
+
+	if err := srv.Serve(l); err != nil {
+		log.Fatal(err)
+	}
+
+	return nil
+}
+
+// isLoopback reports whether the host only accepts connections from the same
+// machine. An empty host listens on all interfaces.
+func isLoopback(host string) bool {
+	if strings.EqualFold(host, "localhost") {
+		return true
+	}
+
+	ip := net.ParseIP(host)
+
+	return ip != nil && ip.IsLoopback()
+}
+
+// requireToken rejects requests without the given bearer token with 401.
+func requireToken(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		scheme, given, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+		if !strings.EqualFold(scheme, "Bearer") || subtle.ConstantTimeCompare([]byte(given), []byte(token)) != 1 {
+			slog.Warn("Rejected unauthenticated approval request.",
+				slog.String("remote_addr", r.RemoteAddr),
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path))
+
+			w.Header().Set("WWW-Authenticate", `Bearer realm="approval"`)
+			writeError(w, http.StatusUnauthorized, errors.New("valid bearer token required"))
+
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) handleList(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, s.Queue.PendingChanges())
+}
+
+func (s *Server) handleApprove(w http.ResponseWriter, r *http.Request) {
+	s.handleDecision(w, r, s.Queue.ApproveChange)
+}
+
+func (s *Server) handleReject(w http.ResponseWriter, r *http.Request) {
+	s.handleDecision(w, r, s.Queue.RejectChange)
+}
+
+func (s *Server) handleDecision(w http.ResponseWriter, r *http.Request, decide func(context.Context, string) error) {
+	err := decide(r.Context(), r.PathValue("id"))
+	switch {
+	case errors.Is(err, bunny.ErrPendingChangeNotFound):
+		writeError(w, http.StatusNotFound, err)
+	case err != nil:
+		slog.ErrorContext(r.Context(), "Failed to decide on pending change.",
+			slog.String("id", r.PathValue("id")),
+			slog.Any("error", err))
+
+		writeError(w, http.StatusInternalServerError, err)
+	default:
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, body any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	//nolint:errcheck // The status has already been written, nothing to do on failure.
+	json.NewEncoder(w).Encode(body)
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]string{"error": err.Error()})
+}
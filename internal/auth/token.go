@@ -0,0 +1,33 @@
+// Package auth holds the credential handling shared by the HTTP servers of
+// the webhook.
+package auth
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Token returns the bearer token configured either directly or through a
+// file holding it, or an empty string if neither is set.
+func Token(token string, tokenFile string) (string, error) {
+	if token != "" && tokenFile != "" {
+		return "", fmt.Errorf("only one of the auth token and the auth token file can be set")
+	}
+
+	if tokenFile == "" {
+		return token, nil
+	}
+
+	data, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read auth token file: %w", err)
+	}
+
+	token = strings.TrimSpace(string(data))
+	if token == "" {
+		return "", fmt.Errorf("auth token file %q is empty", tokenFile)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,175 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+// adoptExisting handles a failed create by looking for an existing record
+// with the same name, type and value in the zone, for example one created by
+// hand or left behind by an earlier crash. If one is found, it is adopted
+// instead: its TTL and provider-specific settings are updated to match the
+// request if they differ. It returns false if no record could be adopted, in
+// which case the create error stands.
+func (p *Provider) adoptExisting(ctx context.Context, zoneID int64, request CreateRecordRequest, createErr error) (*appliedChange, bool) {
+	if !isConflict(createErr) {
+		return nil, false
+	}
+
+	// The cached zones are good enough to find the record, and if it was
+	// created after they were loaded, the next attempt finds it after the
+	// failed batch invalidated them.
+	zones, err := p.zones(ctx)
+	if err != nil {
+		logger(ctx).WarnContext(ctx, "Failed to fetch zones to look for an existing record.",
+			slog.Any("error", err))
+
+		return nil, false
+	}
+
+	var zone *Zone
+	for _, z := range zones {
+		if z.ID == zoneID {
+			zone = z
+			break
+		}
+	}
+
+	if zone == nil {
+		return nil, false
+	}
+
+	var existing *Record
+	for _, record := range zone.Records {
+		if record.Name == request.Name && record.Type == request.Type && sameRecordValue(record, request) {
+			existing = record
+			break
+		}
+	}
+
+	if existing == nil {
+		return nil, false
+	}
+
+	// Adopting a record is a change to a record the provider did not
+	// create, which protection must refuse like any other.
+	if p.Options.ProtectUnmanaged && !p.isManaged(zone, existing) && !p.isUnmanagedAllowed(dnsNameOf(zone.Domain, existing.Name)) {
+		logger(ctx).WarnContext(ctx, "Refusing to adopt existing record not managed by external-dns.",
+			slog.String("zone", zone.Domain),
+			slog.Int64("zone_id", zone.ID),
+			slog.Group("record",
+				slog.Int64("id", existing.ID),
+				slog.String("name", existing.Name),
+				slog.String("type", existing.Type.String()),
+				slog.String("value", existing.Value),
+			))
+
+		return nil, false
+	}
+
+	wanted := adoptedRecord(existing, request)
+	if recordsEqual(existing, wanted) {
+		logger(ctx).InfoContext(ctx, "Adopted existing record.",
+			slog.String("zone", zone.Domain),
+			slog.Int64("zone_id", zone.ID),
+			slog.Group("record",
+				slog.Int64("id", existing.ID),
+				slog.String("name", existing.Name),
+				slog.String("type", existing.Type.String()),
+				slog.String("value", existing.Value),
+				slog.Int("ttl", existing.TTLSeconds),
+			))
+
+		return &appliedChange{Kind: ChangeKindUpdate, ZoneID: zone.ID, RecordID: existing.ID, Previous: existing, Record: existing}, true
+	}
+
+	if err := p.client.UpdateRecord(ctx, zone.ID, existing.ID, updateRequestFromRecord(wanted)); err != nil {
+		logger(ctx).ErrorContext(ctx, "Failed to update existing record while adopting it.",
+			slog.String("zone", zone.Domain),
+			slog.Int64("zone_id", zone.ID),
+			slog.Int64("record_id", existing.ID),
+			slog.Any("error", err))
+
+		return nil, false
+	}
+
+	logger(ctx).InfoContext(ctx, "Adopted and updated existing record.",
+		slog.String("zone", zone.Domain),
+		slog.Int64("zone_id", zone.ID),
+		slog.Group("record",
+			slog.Int64("id", existing.ID),
+			slog.String("name", existing.Name),
+			slog.String("type", existing.Type.String()),
+			slog.String("value", existing.Value),
+			slog.Int("previous_ttl", existing.TTLSeconds),
+			slog.Int("ttl", wanted.TTLSeconds),
+			slog.String("monitor_type", wanted.MonitorType.String()),
+			slog.Int("weight", wanted.Weight),
+			slog.Bool("disabled", wanted.Disabled),
+		))
+
+	return &appliedChange{Kind: ChangeKindUpdate, ZoneID: zone.ID, RecordID: existing.ID, Previous: existing, Record: wanted}, true
+}
+
+// isConflict reports whether the create request was rejected because the
+// record already exists. Bunny.net reports some duplicates as a bad request,
+// which are told apart from other bad requests by their error key.
+func isConflict(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	switch apiErr.StatusCode {
+	case http.StatusConflict:
+		return true
+	case http.StatusBadRequest:
+		key := strings.ToLower(apiErr.ErrorKey)
+		return strings.Contains(key, "duplicate") || strings.Contains(key, "exists")
+	}
+
+	return false
+}
+
+// sameRecordValue reports whether the existing record holds the value of the
+// create request. Scriptable records are identified by their script.
+func sameRecordValue(existing *Record, request CreateRecordRequest) bool {
+	if request.Type == RecordTypeSCR {
+		return existing.ScriptID == request.ScriptID
+	}
+
+	return strings.EqualFold(strings.TrimSuffix(existing.Value, "."), strings.TrimSuffix(request.Value, "."))
+}
+
+// adoptedRecord returns the existing record with the settings of the create
+// request applied.
+func adoptedRecord(existing *Record, request CreateRecordRequest) *Record {
+	wanted := *existing
+
+	wanted.TTLSeconds = request.TTLSeconds
+	if wanted.TTLSeconds == 0 {
+		wanted.TTLSeconds = defaultTTLSeconds
+	}
+
+	wanted.MonitorType = request.MonitorType
+	wanted.Weight = request.Weight
+	wanted.Disabled = request.Disabled
+	wanted.RedirectStatusCode = request.RedirectStatusCode
+	wanted.RedirectPreservePath = request.RedirectPreservePath
+	wanted.EnvironmentVariables = request.EnvironmentVariables
+	wanted.Comment = request.Comment
+
+	return &wanted
+}
+
+// dnsNameOf returns the fully qualified name of a record in the given zone.
+func dnsNameOf(domain string, recordName string) string {
+	if recordName == "" {
+		return domain
+	}
+
+	return recordName + "." + domain
+}
@@ -1,21 +1,37 @@
 package bunny
 
 import (
+	"fmt"
+	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"sigs.k8s.io/external-dns/endpoint"
 )
 
 const (
-	providerSpecificDisabled    = "webhook/bunny-disabled"
-	providerSpecificMonitorType = "webhook/bunny-monitor-type"
-	providerSpecificWeight      = "webhook/bunny-weight"
+	providerSpecificDisabled             = "webhook/bunny-disabled"
+	providerSpecificMonitorType          = "webhook/bunny-monitor-type"
+	providerSpecificWeight               = "webhook/bunny-weight"
+	providerSpecificRedirect             = "webhook/bunny-redirect"
+	providerSpecificRedirectStatusCode   = "webhook/bunny-redirect-status-code"
+	providerSpecificRedirectPreservePath = "webhook/bunny-redirect-preserve-path"
+	providerSpecificScriptID             = "webhook/bunny-script-id"
+	providerSpecificScriptEnv            = "webhook/bunny-script-env"
+	providerSpecificComment              = "webhook/bunny-comment"
 )
 
 type providerSpecificOptions struct {
-	Disabled    bool
-	MonitorType MonitorType
-	Weight      int
+	Disabled             bool
+	MonitorType          MonitorType
+	Weight               int
+	Redirect             string
+	RedirectStatusCode   int
+	RedirectPreservePath bool
+	ScriptID             int64
+	ScriptEnv            []EnvironmentVariable
+	Comment              string
 }
 
 func providerSpecificOptionsFromEndpoint(e *endpoint.Endpoint) (providerSpecificOptions, error) {
@@ -53,6 +69,58 @@ func providerSpecificOptionsFromEndpoint(e *endpoint.Endpoint) (providerSpecific
 		opts.Weight = 100
 	}
 
+	if redirect, ok := e.GetProviderSpecificProperty(providerSpecificRedirect); ok {
+		opts.Redirect = redirect
+	}
+
+	if statusCode, ok := e.GetProviderSpecificProperty(providerSpecificRedirectStatusCode); ok {
+		var err error
+		opts.RedirectStatusCode, err = strconv.Atoi(statusCode)
+		if err != nil {
+			opts.RedirectStatusCode = http.StatusMovedPermanently
+		}
+	}
+
+	// Only the permanent and temporary redirect status codes are accepted by
+	// Bunny.net, anything else falls back to a permanent redirect.
+	switch opts.RedirectStatusCode {
+	case http.StatusMovedPermanently, http.StatusFound, http.StatusTemporaryRedirect, http.StatusPermanentRedirect:
+	default:
+		opts.RedirectStatusCode = http.StatusMovedPermanently
+	}
+
+	if preservePath, ok := e.GetProviderSpecificProperty(providerSpecificRedirectPreservePath); ok {
+		var err error
+		opts.RedirectPreservePath, err = strconv.ParseBool(preservePath)
+		if err != nil {
+			opts.RedirectPreservePath = false
+		}
+	}
+
+	if scriptID, ok := e.GetProviderSpecificProperty(providerSpecificScriptID); ok {
+		var err error
+		opts.ScriptID, err = strconv.ParseInt(scriptID, 10, 64)
+		if err != nil || opts.ScriptID < 1 {
+			return opts, fmt.Errorf("invalid script ID %q for %q", scriptID, e.DNSName)
+		}
+	}
+
+	if env, ok := e.GetProviderSpecificProperty(providerSpecificScriptEnv); ok {
+		var err error
+		opts.ScriptEnv, err = parseScriptEnv(env)
+		if err != nil {
+			return opts, fmt.Errorf("invalid script environment for %q: %w", e.DNSName, err)
+		}
+	}
+
+	if comment, ok := e.GetProviderSpecificProperty(providerSpecificComment); ok {
+		opts.Comment = comment
+	}
+
+	if opts.IsRedirect() && opts.IsScript() {
+		return opts, fmt.Errorf("%q cannot be both a redirect and a script record", e.DNSName)
+	}
+
 	return opts, nil
 }
 
@@ -61,13 +129,125 @@ func providerSpecificOptionsFromRecord(r *Record) *providerSpecificOptions {
 		MonitorType: r.MonitorType,
 		Weight:      r.Weight,
 		Disabled:    r.Disabled,
+		Comment:     existingComment(r),
+	}
+
+	if r.Type == RecordTypeRDR {
+		opts.Redirect = r.Value
+		opts.RedirectStatusCode = r.RedirectStatusCode
+		opts.RedirectPreservePath = r.RedirectPreservePath
+
+		if opts.RedirectStatusCode == 0 {
+			opts.RedirectStatusCode = http.StatusMovedPermanently
+		}
+	}
+
+	if r.Type == RecordTypeSCR {
+		opts.ScriptID = r.ScriptID
+		opts.ScriptEnv = sortScriptEnv(r.EnvironmentVariables)
 	}
 
 	return opts
 }
 
+// IsRedirect reports whether the options describe a Bunny.net redirect record.
+func (p *providerSpecificOptions) IsRedirect() bool {
+	return p.Redirect != ""
+}
+
+// IsScript reports whether the options describe a Bunny.net scriptable DNS
+// record.
+func (p *providerSpecificOptions) IsScript() bool {
+	return p.ScriptID != 0
+}
+
 func (p *providerSpecificOptions) ApplyToEndpoint(e *endpoint.Endpoint) {
-	e.WithProviderSpecific(providerSpecificMonitorType, p.MonitorType.String())
-	e.WithProviderSpecific(providerSpecificWeight, strconv.Itoa(p.Weight))
-	e.WithProviderSpecific(providerSpecificDisabled, strconv.FormatBool(p.Disabled))
+	e.SetProviderSpecificProperty(providerSpecificMonitorType, p.MonitorType.String())
+	e.SetProviderSpecificProperty(providerSpecificWeight, strconv.Itoa(p.Weight))
+	e.SetProviderSpecificProperty(providerSpecificDisabled, strconv.FormatBool(p.Disabled))
+
+	if p.IsRedirect() {
+		e.SetProviderSpecificProperty(providerSpecificRedirect, p.Redirect)
+		e.SetProviderSpecificProperty(providerSpecificRedirectStatusCode, strconv.Itoa(p.RedirectStatusCode))
+		e.SetProviderSpecificProperty(providerSpecificRedirectPreservePath, strconv.FormatBool(p.RedirectPreservePath))
+	}
+
+	if p.IsScript() {
+		e.SetProviderSpecificProperty(providerSpecificScriptID, strconv.FormatInt(p.ScriptID, 10))
+		e.SetProviderSpecificProperty(providerSpecificScriptEnv, formatScriptEnv(p.ScriptEnv))
+	}
+
+	if p.Comment != "" {
+		e.SetProviderSpecificProperty(providerSpecificComment, p.Comment)
+	}
+}
+
+// parseScriptEnv parses a comma separated list of KEY=VALUE pairs into the
+// environment variables passed to a DNS script.
+func parseScriptEnv(s string) ([]EnvironmentVariable, error) {
+	var env []EnvironmentVariable
+
+	for _, pair := range splitEscaped(s, ',') {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		name, value, ok := strings.Cut(pair, "=")
+		if !ok || name == "" {
+			return nil, fmt.Errorf("expected KEY=VALUE, got %q", pair)
+		}
+
+		env = append(env, EnvironmentVariable{Name: name, Value: value})
+	}
+
+	return sortScriptEnv(env), nil
+}
+
+// formatScriptEnv formats environment variables in the same form accepted by
+// parseScriptEnv.
+func formatScriptEnv(env []EnvironmentVariable) string {
+	pairs := make([]string, 0, len(env))
+	for _, v := range env {
+		pairs = append(pairs, scriptEnvEscaper.Replace(v.Name)+"="+scriptEnvEscaper.Replace(v.Value))
+	}
+
+	return strings.Join(pairs, ",")
+}
+
+// scriptEnvEscaper escapes the separator of environment variables, so values
+// containing commas survive a round trip through formatScriptEnv.
+var scriptEnvEscaper = strings.NewReplacer(`\`, `\\`, `,`, `\,`)
+
+// splitEscaped splits s at every sep not preceded by a backslash, and
+// unescapes the backslash escapes in the parts.
+func splitEscaped(s string, sep byte) []string {
+	var parts []string
+	var b strings.Builder
+
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s):
+			i++
+			b.WriteByte(s[i])
+		case s[i] == sep:
+			parts = append(parts, b.String())
+			b.Reset()
+		default:
+			b.WriteByte(s[i])
+		}
+	}
+
+	return append(parts, b.String())
+}
+
+// sortScriptEnv returns a copy of the environment variables ordered by name,
+// so that they always serialize the same way regardless of their source.
+func sortScriptEnv(env []EnvironmentVariable) []EnvironmentVariable {
+	sorted := slices.Clone(env)
+	slices.SortFunc(sorted, func(a, b EnvironmentVariable) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+
+	return sorted
 }
@@ -0,0 +1,75 @@
+package bunny
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSplitEscaped(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{in: "", want: []string{""}},
+		{in: "a", want: []string{"a"}},
+		{in: "a,b", want: []string{"a", "b"}},
+		{in: "a,,b", want: []string{"a", "", "b"}},
+		{in: `a\,b,c`, want: []string{"a,b", "c"}},
+		{in: `a\\,b`, want: []string{`a\`, "b"}},
+		{in: `a\`, want: []string{`a\`}},
+		{in: "a,", want: []string{"a", ""}},
+	}
+
+	for _, tt := range tests {
+		if got := splitEscaped(tt.in, ','); !slices.Equal(got, tt.want) {
+			t.Errorf("splitEscaped(%q) = %q, want %q", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestParseScriptEnv(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    []EnvironmentVariable
+		wantErr bool
+	}{
+		{in: "", want: nil},
+		{in: "A=1", want: []EnvironmentVariable{{Name: "A", Value: "1"}}},
+		{in: "B=2, A=1", want: []EnvironmentVariable{{Name: "A", Value: "1"}, {Name: "B", Value: "2"}}},
+		{in: "A=", want: []EnvironmentVariable{{Name: "A", Value: ""}}},
+		{in: "A=x=y", want: []EnvironmentVariable{{Name: "A", Value: "x=y"}}},
+		{in: `A=1\,2,B=3`, want: []EnvironmentVariable{{Name: "A", Value: "1,2"}, {Name: "B", Value: "3"}}},
+		{in: "A=1,,", want: []EnvironmentVariable{{Name: "A", Value: "1"}}},
+		{in: "A", wantErr: true},
+		{in: "=1", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseScriptEnv(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseScriptEnv(%q) error = %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("parseScriptEnv(%q) = %v, want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestScriptEnvRoundTrip(t *testing.T) {
+	env := []EnvironmentVariable{
+		{Name: "A", Value: `C:\path`},
+		{Name: "B", Value: "x,y"},
+		{Name: "C", Value: ""},
+	}
+
+	got, err := parseScriptEnv(formatScriptEnv(env))
+	if err != nil {
+		t.Fatalf("parseScriptEnv() error = %v", err)
+	}
+
+	if !slices.Equal(got, env) {
+		t.Errorf("round trip = %v, want %v", got, env)
+	}
+}
@@ -0,0 +1,241 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/provider"
+)
+
+// errUnmanagedRecord is the outcome of changes refused because the record is
+// not managed by the provider.
+var errUnmanagedRecord = errors.New("record is not managed by external-dns")
+
+// ChangeOutcome is the outcome of a single change in a batch. A nil Err
+// means the change was applied.
+type ChangeOutcome struct {
+	Kind     string
+	Endpoint *endpoint.Endpoint
+	Err      error
+}
+
+// ApplyError is returned when changes in a batch failed. It holds the outcome
+// of every change that was attempted.
+type ApplyError struct {
+	Outcomes []ChangeOutcome
+}
+
+// Failed returns the outcomes of the changes that failed.
+func (e *ApplyError) Failed() []ChangeOutcome {
+	var failed []ChangeOutcome
+	for _, outcome := range e.Outcomes {
+		if outcome.Err != nil {
+			failed = append(failed, outcome)
+		}
+	}
+
+	return failed
+}
+
+func (e *ApplyError) Error() string {
+	failed := e.Failed()
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "failed to apply %d of %d changes", len(failed), len(e.Outcomes))
+
+	for i, outcome := range failed {
+		if i == 0 {
+			b.WriteString(": ")
+		} else {
+			b.WriteString("; ")
+		}
+
+		fmt.Fprintf(&b, "%s %s (%s): %v", outcome.Kind, outcome.Endpoint.DNSName, outcome.Endpoint.RecordType, outcome.Err)
+	}
+
+	return b.String()
+}
+
+func (e *ApplyError) Unwrap() []error {
+	var errs []error
+	for _, outcome := range e.Failed() {
+		errs = append(errs, outcome.Err)
+	}
+
+	return errs
+}
+
+// stepResult is the result of a single step of a batch. done is closed once
+// the step has been applied or skipped.
+type stepResult struct {
+	change    *appliedChange
+	err       error
+	attempted bool
+	logs      *logBuffer
+	done      chan struct{}
+}
+
+// applySteps applies the steps using up to Concurrency workers, recording
+// the outcome of every step and the changes that were applied in the order
+// of the steps. Steps for the same DNS name are applied one after another in
+// their original order. Unless the provider is configured to continue on
+// errors, no further steps are started after the first failure, and the
+// first failed step is returned.
+func (p *Provider) applySteps(ctx context.Context, steps []changeStep, identifiers map[endpoint.EndpointKey]identifierTuple, outcomes *[]ChangeOutcome, applied *[]*appliedChange) error {
+	// A transactional batch is rolled back as soon as a change fails, so
+	// there is no point in attempting the remaining changes.
+	stopOnError := !p.Options.ContinueOnError || p.Options.Transactional
+
+	results := make([]*stepResult, len(steps))
+	for i := range steps {
+		results[i] = &stepResult{
+			logs: newLogBuffer(logger(ctx).Handler()),
+			done: make(chan struct{}),
+		}
+	}
+
+	// The logs of every step are written once the step and all steps
+	// before it are done.
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+
+		for _, result := range results {
+			<-result.done
+			result.logs.flush(ctx)
+		}
+	}()
+
+	var wg sync.WaitGroup
+	var stopped atomic.Bool
+
+	concurrency := max(p.Options.Concurrency, 1)
+	workers := make(chan struct{}, concurrency)
+	previous := make(map[string]*stepResult)
+
+	// A step waits for the previous step for the same name before it takes
+	// a worker, so the order of changes to a name is kept without holding
+	// up the steps for other names. With a single worker every step waits
+	// for the one before it, so the steps are applied strictly in order.
+	for i, step := range steps {
+		result := results[i]
+
+		key := step.Endpoint.DNSName
+		if concurrency == 1 {
+			key = ""
+		}
+
+		prev := previous[key]
+		previous[key] = result
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(result.done)
+
+			if prev != nil {
+				<-prev.done
+			}
+
+			workers <- struct{}{}
+			defer func() { <-workers }()
+
+			if stopped.Load() {
+				return
+			}
+
+			stepCtx := withLogger(ctx, slog.New(result.logs))
+			result.change, result.err = p.applyStep(stepCtx, step, identifiers)
+			result.attempted = true
+
+			if result.err != nil && stopOnError {
+				stopped.Store(true)
+			}
+		}()
+	}
+
+	wg.Wait()
+	<-flushed
+
+	var firstErr error
+	for i, result := range results {
+		if !result.attempted {
+			continue
+		}
+
+		*outcomes = append(*outcomes, ChangeOutcome{Kind: steps[i].Kind, Endpoint: steps[i].Endpoint, Err: result.err})
+
+		if result.change != nil {
+			*applied = append(*applied, result.change)
+		}
+
+		if result.err != nil && stopOnError && firstErr == nil {
+			firstErr = fmt.Errorf("failed to %s record %q: %w", steps[i].Kind, steps[i].Endpoint.DNSName, result.err)
+		}
+	}
+
+	return firstErr
+}
+
+// applyStep applies a single step.
+func (p *Provider) applyStep(ctx context.Context, step changeStep, identifiers map[endpoint.EndpointKey]identifierTuple) (*appliedChange, error) {
+	switch step.Kind {
+	case ChangeKindCreate:
+		return p.createEndpoint(ctx, step.Endpoint)
+	case ChangeKindUpdate:
+		return p.updateEndpoint(ctx, identifiers, step.Endpoint)
+	case ChangeKindDelete:
+		return p.deleteEndpoint(ctx, identifiers, step.Endpoint)
+	}
+
+	return nil, fmt.Errorf("unknown change kind %q", step.Kind)
+}
+
+// outcomeError summarizes the outcomes of a batch. It returns nil if every
+// change was applied, and a soft error if only some changes failed or the
+// only failures are refused changes to unmanaged records, since retrying
+// the whole batch would not help those.
+func outcomeError(ctx context.Context, outcomes []ChangeOutcome) error {
+	applyErr := &ApplyError{Outcomes: outcomes}
+
+	failed := applyErr.Failed()
+	if len(failed) == 0 {
+		return nil
+	}
+
+	var refused int
+	for _, outcome := range failed {
+		if errors.Is(outcome.Err, errUnmanagedRecord) {
+			refused++
+		}
+	}
+
+	slog.WarnContext(ctx, "Some changes could not be applied.",
+		slog.Int("changes", len(outcomes)),
+		slog.Int("applied", len(outcomes)-len(failed)),
+		slog.Int("failed", len(failed)-refused),
+		slog.Int("refused", refused))
+
+	for _, outcome := range failed {
+		slog.WarnContext(ctx, "Change not applied.",
+			slog.String("kind", outcome.Kind),
+			slog.Group("record",
+				slog.String("name", outcome.Endpoint.DNSName),
+				slog.String("type", outcome.Endpoint.RecordType),
+				slog.Any("value", outcome.Endpoint.Targets),
+			),
+			slog.Any("error", outcome.Err))
+	}
+
+	if len(failed) < len(outcomes) || refused == len(failed) {
+		return provider.NewSoftError(applyErr)
+	}
+
+	return applyErr
+}
@@ -0,0 +1,163 @@
+package bunny
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func createSteps(names ...string) []changeStep {
+	steps := make([]changeStep, 0, len(names))
+	for i, name := range names {
+		target := fmt.Sprintf("1.1.1.%d", i+1)
+		steps = append(steps, changeStep{
+			Kind:     ChangeKindCreate,
+			Endpoint: endpoint.NewEndpoint(name+".example.com", endpoint.RecordTypeA, target),
+		})
+	}
+
+	return steps
+}
+
+func TestApplyStepsSerializesChangesToSameName(t *testing.T) {
+	p := newTestProvider(Options{Concurrency: 4}, &Zone{ID: 1, Domain: "example.com"})
+	client := p.client.(*fakeClient)
+
+	// The first change to a is held until b has been created, so b must not
+	// wait for a, while the second change to a must.
+	createdB := make(chan struct{})
+	var heldA bool
+	client.before = func(call string) {
+		switch {
+		case call == "create b":
+			close(createdB)
+		case call == "create a" && !heldA:
+			heldA = true
+			select {
+			case <-createdB:
+			case <-time.After(5 * time.Second):
+				t.Error("change to b was held up by the change to a")
+			}
+		}
+	}
+
+	var logs bytes.Buffer
+	ctx := withLogger(context.Background(), slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))
+
+	var outcomes []ChangeOutcome
+	var applied []*appliedChange
+	if err := p.applySteps(ctx, createSteps("a", "a", "b"), nil, &outcomes, &applied); err != nil {
+		t.Fatalf("applySteps() error = %v", err)
+	}
+
+	if want := []string{"create b", "create a", "create a"}; !slices.Equal(client.calls, want) {
+		t.Errorf("client calls = %v, want %v", client.calls, want)
+	}
+
+	// Outcomes, applied changes and logs follow the order of the steps,
+	// not the order the changes finished in.
+	var names, values []string
+	for _, outcome := range outcomes {
+		names = append(names, outcome.Endpoint.DNSName)
+	}
+
+	for _, change := range applied {
+		values = append(values, change.Record.Value)
+	}
+
+	if want := []string{"a.example.com", "a.example.com", "b.example.com"}; !slices.Equal(names, want) {
+		t.Errorf("outcomes = %v, want %v", names, want)
+	}
+
+	if want := []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}; !slices.Equal(values, want) {
+		t.Errorf("applied values = %v, want %v", values, want)
+	}
+
+	var logged []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		if strings.Contains(line, "Creating Record.") {
+			_, value, _ := strings.Cut(line, "record.value=")
+			value, _, _ = strings.Cut(value, " ")
+			logged = append(logged, value)
+		}
+	}
+
+	if want := []string{"1.1.1.1", "1.1.1.2", "1.1.1.3"}; !slices.Equal(logged, want) {
+		t.Errorf("logged values = %v, want %v", logged, want)
+	}
+}
+
+func TestApplyStepsFailures(t *testing.T) {
+	tests := []struct {
+		name     string
+		options  Options
+		calls    []string
+		outcomes int
+		wantErr  bool
+	}{
+		{
+			name:     "stop on error",
+			options:  Options{Concurrency: 1},
+			calls:    []string{"create a", "create b"},
+			outcomes: 2,
+			wantErr:  true,
+		},
+		{
+			name:     "continue on error",
+			options:  Options{Concurrency: 1, ContinueOnError: true},
+			calls:    []string{"create a", "create b", "create c"},
+			outcomes: 3,
+		},
+		{
+			name:     "transactional",
+			options:  Options{Concurrency: 1, ContinueOnError: true, Transactional: true},
+			calls:    []string{"create a", "create b"},
+			outcomes: 2,
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(tt.options, &Zone{ID: 1, Domain: "example.com"})
+			client := p.client.(*fakeClient)
+			client.fail = func(call string) error {
+				if call == "create b" {
+					return errors.New("failed")
+				}
+
+				return nil
+			}
+
+			ctx := withLogger(context.Background(), slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+			var outcomes []ChangeOutcome
+			var applied []*appliedChange
+			err := p.applySteps(ctx, createSteps("a", "b", "c"), nil, &outcomes, &applied)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applySteps() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if !slices.Equal(client.calls, tt.calls) {
+				t.Errorf("client calls = %v, want %v", client.calls, tt.calls)
+			}
+
+			if len(outcomes) != tt.outcomes {
+				t.Errorf("got %d outcomes, want %d", len(outcomes), tt.outcomes)
+			}
+
+			if failed := (&ApplyError{Outcomes: outcomes}).Failed(); len(failed) != 1 || failed[0].Endpoint.DNSName != "b.example.com" {
+				t.Errorf("failed outcomes = %v, want the change to b", failed)
+			}
+		})
+	}
+}
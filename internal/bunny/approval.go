@@ -0,0 +1,462 @@
+package bunny
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"github.com/samber/oops"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const approvalQueueVersion = 1
+
+const (
+	ChangeKindCreate = "create"
+	ChangeKindUpdate = "update"
+	ChangeKindDelete = "delete"
+)
+
+// PendingStatus is the status of a change parked in the approval queue.
+type PendingStatus string
+
+const (
+	PendingStatusPending  PendingStatus = "pending"
+	PendingStatusRejected PendingStatus = "rejected"
+)
+
+// ErrPendingChangeNotFound is returned when a change is not in the approval
+// queue.
+var ErrPendingChangeNotFound = errors.New("pending change not found")
+
+// PendingChange is a change parked in the approval queue until an operator
+// approves or rejects it.
+type PendingChange struct {
+	ID        string             `json:"id"`
+	Kind      string             `json:"kind"`
+	Zone      string             `json:"zone"`
+	Status    PendingStatus      `json:"status"`
+	Endpoint  *endpoint.Endpoint `json:"endpoint"`
+	Previous  *endpoint.Endpoint `json:"previous,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	Error     string             `json:"error,omitempty"`
+
+	// Registry holds the TXT registry records changed along with the
+	// record, so ownership is only recorded once the change is applied.
+	Registry         []*endpoint.Endpoint `json:"registry,omitempty"`
+	RegistryPrevious []*endpoint.Endpoint `json:"registry_previous,omitempty"`
+
+	// applying is set while the change is being applied after it has been
+	// approved.
+	applying bool
+}
+
+// same reports whether both changes would make the same change to the same
+// record.
+func (c *PendingChange) same(o *PendingChange) bool {
+	return c.Kind == o.Kind &&
+		c.Endpoint.DNSName == o.Endpoint.DNSName &&
+		c.Endpoint.RecordType == o.Endpoint.RecordType &&
+		c.Endpoint.RecordTTL == o.Endpoint.RecordTTL &&
+		c.Endpoint.Targets.Same(o.Endpoint.Targets) &&
+		slices.Equal(c.Endpoint.ProviderSpecific, o.Endpoint.ProviderSpecific)
+}
+
+// slot reports whether both changes target the same record.
+func (c *PendingChange) slot(o *PendingChange) bool {
+	return c.Kind == o.Kind &&
+		c.Endpoint.DNSName == o.Endpoint.DNSName &&
+		c.Endpoint.RecordType == o.Endpoint.RecordType
+}
+
+// changes returns the change as a plan with a single change.
+func (c *PendingChange) changes() *plan.Changes {
+	endpoints := append([]*endpoint.Endpoint{c.Endpoint}, c.Registry...)
+
+	switch c.Kind {
+	case ChangeKindCreate:
+		return &plan.Changes{Create: endpoints}
+	case ChangeKindUpdate:
+		previous := append([]*endpoint.Endpoint{c.Previous}, c.RegistryPrevious...)
+		return &plan.Changes{UpdateOld: previous, UpdateNew: endpoints}
+	default:
+		return &plan.Changes{Delete: endpoints}
+	}
+}
+
+// approvalQueue holds the changes waiting for approval and persists them to a
+// file, so they survive restarts.
+type approvalQueue struct {
+	path    string
+	mu      sync.Mutex
+	changes []*PendingChange
+}
+
+type approvalQueueFile struct {
+	Version int              `json:"version"`
+	Changes []*PendingChange `json:"changes"`
+}
+
+func newApprovalQueue(path string) (*approvalQueue, error) {
+	q := &approvalQueue{path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return q, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	var file approvalQueueFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	if file.Version != approvalQueueVersion {
+		return nil, oops.Errorf("unsupported approval queue version %d", file.Version)
+	}
+
+	q.changes = file.Changes
+
+	return q, nil
+}
+
+// persist writes the queue to its file. The caller must hold the lock.
+func (q *approvalQueue) persist() error {
+	data, err := json.MarshalIndent(approvalQueueFile{
+		Version: approvalQueueVersion,
+		Changes: q.changes,
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(q.path, data)
+}
+
+// requiresApproval reports whether a change of the given kind to a record of
+// the given type in the given zone must be approved before it is applied.
+// Every configured filter has to match, filters that are not configured
+// match any change.
+func (p *Provider) requiresApproval(kind string, zone string, recordType string) bool {
+	matches := func(filter []string, value string) bool {
+		return len(filter) == 0 || slices.ContainsFunc(filter, func(f string) bool {
+			return strings.EqualFold(strings.TrimSuffix(f, "."), value)
+		})
+	}
+
+	return matches(p.Options.ApprovalZones, zone) &&
+		matches(p.Options.ApprovalRecordTypes, recordType) &&
+		matches(p.Options.ApprovalChangeKinds, kind)
+}
+
+// parkChanges moves the changes that require approval from the given changes
+// into the approval queue and returns the changes that may be applied right
+// away. Parked changes that are no longer part of the plan are dropped from
+// the queue, as external-dns plans every outstanding change on each run.
+func (p *Provider) parkChanges(ctx context.Context, changes *plan.Changes) (*plan.Changes, error) {
+	if p.approvals == nil {
+		return changes, nil
+	}
+
+	errs := oops.In("Provider").
+		Span("parkChanges")
+
+	allowed := &plan.Changes{}
+	var planned []*PendingChange
+
+	park := func(kind string, ep *endpoint.Endpoint, previous *endpoint.Endpoint) bool {
+		_, zone, _ := extractRecordComponents(p.allZones(), ep.DNSName)
+		if !p.requiresApproval(kind, zone, ep.RecordType) {
+			return false
+		}
+
+		planned = append(planned, &PendingChange{
+			Kind:     kind,
+			Zone:     zone,
+			Status:   PendingStatusPending,
+			Endpoint: ep,
+			Previous: previous,
+		})
+
+		return true
+	}
+
+	// Registry records are parked along with the record they own, so the
+	// ownership is not recorded before the record itself has been changed.
+	// Only registry records without a parked owner are handled on their own.
+	owners := make(map[string]*PendingChange)
+	attach := func(kind string, ep *endpoint.Endpoint, previous *endpoint.Endpoint) bool {
+		owner, ok := owners[kind+"/"+strings.ToLower(strings.TrimSuffix(ep.DNSName, "."))]
+		if !ok {
+			return false
+		}
+
+		owner.Registry = append(owner.Registry, ep)
+		if previous != nil {
+			owner.RegistryPrevious = append(owner.RegistryPrevious, previous)
+		}
+
+		return true
+	}
+
+	previousOf := func(ep *endpoint.Endpoint) *endpoint.Endpoint {
+		previous, _ := lo.Find(changes.UpdateOld, func(old *endpoint.Endpoint) bool {
+			return old.DNSName == ep.DNSName && old.RecordType == ep.RecordType
+		})
+
+		return previous
+	}
+
+	for _, registry := range []bool{false, true} {
+		handle := park
+		if registry {
+			for _, change := range planned {
+				for _, name := range p.registry.names(change.Endpoint) {
+					owners[change.Kind+"/"+name] = change
+				}
+			}
+
+			handle = func(kind string, ep *endpoint.Endpoint, previous *endpoint.Endpoint) bool {
+				return attach(kind, ep, previous) || park(kind, ep, previous)
+			}
+		}
+
+		for _, ep := range changes.Create {
+			if isRegistryRecord(ep) != registry {
+				continue
+			}
+
+			if !handle(ChangeKindCreate, ep, nil) {
+				allowed.Create = append(allowed.Create, ep)
+			}
+		}
+
+		for _, ep := range changes.UpdateNew {
+			if isRegistryRecord(ep) != registry {
+				continue
+			}
+
+			previous := previousOf(ep)
+
+			// An update cannot be applied after approval without the
+			// record it replaces, so it is neither parked nor applied.
+			_, zone, _ := extractRecordComponents(p.allZones(), ep.DNSName)
+			if previous == nil && p.requiresApproval(ChangeKindUpdate, zone, ep.RecordType) {
+				slog.WarnContext(ctx, "Skipping update requiring approval without the previous record.",
+					slog.String("zone", zone),
+					slog.Group("record",
+						slog.String("name", ep.DNSName),
+						slog.String("type", ep.RecordType),
+					))
+
+				continue
+			}
+
+			if !handle(ChangeKindUpdate, ep, previous) {
+				allowed.UpdateNew = append(allowed.UpdateNew, ep)
+				if previous != nil {
+					allowed.UpdateOld = append(allowed.UpdateOld, previous)
+				}
+			}
+		}
+
+		for _, ep := range changes.Delete {
+			if isRegistryRecord(ep) != registry {
+				continue
+			}
+
+			if !handle(ChangeKindDelete, ep, nil) {
+				allowed.Delete = append(allowed.Delete, ep)
+			}
+		}
+	}
+
+	p.approvals.mu.Lock()
+	defer p.approvals.mu.Unlock()
+
+	var queue []*PendingChange
+	for _, change := range planned {
+		existing, ok := lo.Find(p.approvals.changes, change.slot)
+		if ok && existing.same(change) {
+			// Already queued, keep the existing entry and its status, so
+			// rejected changes stay rejected.
+			queue = append(queue, existing)
+			continue
+		}
+
+		change.ID = newPendingChangeID()
+		change.CreatedAt = time.Now().UTC()
+		queue = append(queue, change)
+
+		slog.InfoContext(ctx, "Change parked for approval.",
+			slog.String("id", change.ID),
+			slog.String("kind", change.Kind),
+			slog.String("zone", change.Zone),
+			slog.Group("record",
+				slog.String("name", change.Endpoint.DNSName),
+				slog.String("type", change.Endpoint.RecordType),
+				slog.Any("value", change.Endpoint.Targets),
+				slog.Int("ttl", int(change.Endpoint.RecordTTL)),
+			))
+	}
+
+	p.approvals.changes = queue
+	if err := p.approvals.persist(); err != nil {
+		return nil, errs.Wrapf(err, "failed to persist approval queue")
+	}
+
+	return allowed, nil
+}
+
+// PendingChanges returns the changes in the approval queue.
+func (p *Provider) PendingChanges() []PendingChange {
+	if p.approvals == nil {
+		return nil
+	}
+
+	p.approvals.mu.Lock()
+	defer p.approvals.mu.Unlock()
+
+	changes := make([]PendingChange, 0, len(p.approvals.changes))
+	for _, change := range p.approvals.changes {
+		changes = append(changes, *change)
+	}
+
+	return changes
+}
+
+// ApproveChange applies the change with the given ID and removes it from the
+// approval queue. If applying the change fails, it stays in the queue with
+// the error attached.
+func (p *Provider) ApproveChange(ctx context.Context, id string) error {
+	errs := oops.In("Provider").
+		With("id", id).
+		Span("ApproveChange")
+
+	if p.approvals == nil {
+		return errs.Wrap(ErrPendingChangeNotFound)
+	}
+
+	// The queue is not locked while the change is applied, so external-dns
+	// can keep applying other changes in the meantime.
+	p.approvals.mu.Lock()
+
+	change, ok := lo.Find(p.approvals.changes, func(c *PendingChange) bool { return c.ID == id })
+	if !ok {
+		p.approvals.mu.Unlock()
+		return errs.Wrap(ErrPendingChangeNotFound)
+	}
+
+	if change.applying {
+		p.approvals.mu.Unlock()
+		return errs.Errorf("change is already being applied")
+	}
+
+	change.applying = true
+	changes := change.changes()
+
+	p.approvals.mu.Unlock()
+
+	slog.InfoContext(ctx, "Applying approved change.",
+		slog.String("id", change.ID),
+		slog.String("kind", change.Kind),
+		slog.String("zone", change.Zone),
+		slog.String("name", change.Endpoint.DNSName),
+		slog.String("type", change.Endpoint.RecordType))
+
+	err := p.applyChanges(ctx, changes)
+
+	p.approvals.mu.Lock()
+	defer p.approvals.mu.Unlock()
+
+	change.applying = false
+
+	// The queue may have been replanned while the change was applied, in
+	// which case the change may no longer be part of it.
+	idx := slices.Index(p.approvals.changes, change)
+
+	if err != nil {
+		change.Error = err.Error()
+
+		if idx >= 0 {
+			//nolint:errcheck // The apply error is more relevant than the persist error.
+			p.approvals.persist()
+		}
+
+		return errs.Wrapf(err, "failed to apply approved change")
+	}
+
+	if idx < 0 {
+		return nil
+	}
+
+	p.approvals.changes = slices.Delete(p.approvals.changes, idx, idx+1)
+	if err := p.approvals.persist(); err != nil {
+		return errs.Wrapf(err, "failed to persist approval queue")
+	}
+
+	return nil
+}
+
+// RejectChange marks the change with the given ID as rejected. Rejected
+// changes stay in the queue and are not parked again for as long as
+// external-dns keeps planning the exact same change.
+func (p *Provider) RejectChange(ctx context.Context, id string) error {
+	errs := oops.In("Provider").
+		With("id", id).
+		Span("RejectChange")
+
+	if p.approvals == nil {
+		return errs.Wrap(ErrPendingChangeNotFound)
+	}
+
+	p.approvals.mu.Lock()
+	defer p.approvals.mu.Unlock()
+
+	change, ok := lo.Find(p.approvals.changes, func(c *PendingChange) bool { return c.ID == id })
+	if !ok {
+		return errs.Wrap(ErrPendingChangeNotFound)
+	}
+
+	change.Status = PendingStatusRejected
+
+	slog.InfoContext(ctx, "Change rejected.",
+		slog.String("id", change.ID),
+		slog.String("kind", change.Kind),
+		slog.String("zone", change.Zone),
+		slog.String("name", change.Endpoint.DNSName),
+		slog.String("type", change.Endpoint.RecordType))
+
+	if err := p.approvals.persist(); err != nil {
+		return errs.Wrapf(err, "failed to persist approval queue")
+	}
+
+	return nil
+}
+
+// ApprovalsEnabled reports whether changes may be parked for approval.
+func (p *Provider) ApprovalsEnabled() bool {
+	return p.approvals != nil
+}
+
+func newPendingChangeID() string {
+	b := make([]byte, 8)
+
+	//nolint:errcheck // crypto/rand.Read never returns an error.
+	rand.Read(b)
+
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,377 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// zoneLoadTimeout bounds how long loading the zones of the account may take.
+const zoneLoadTimeout = 2 * time.Minute
+
+// zoneCache holds the zones and records of the account, so a single
+// external-dns loop calling Records, AdjustEndpoints and ApplyChanges only
+// downloads the account once. Invalidated zones are kept as the last known
+// good state of the account. Cached zones are shared between callers and must
+// not be modified.
+type zoneCache struct {
+	ttl time.Duration
+
+	mu        sync.RWMutex
+	zones     []*Zone
+	fetchedAt time.Time
+	valid     bool
+
+	// seeded is set while the zones have been loaded from the state file
+	// and not been refreshed yet. Seeded zones are never fresh, they are
+	// only returned to external-dns as stale records.
+	seeded bool
+
+	// generation is incremented whenever the cache is invalidated, so a
+	// load started before a mutation never overwrites the invalidation.
+	generation uint64
+
+	group singleflight.Group
+}
+
+func newZoneCache(ttl time.Duration) *zoneCache {
+	return &zoneCache{ttl: ttl}
+}
+
+// get returns the cached zones if they are still fresh.
+func (c *zoneCache) get() ([]*Zone, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.valid || c.seeded {
+		return nil, false
+	}
+
+	if c.ttl <= 0 || time.Since(c.fetchedAt) >= c.ttl {
+		return nil, false
+	}
+
+	return c.zones, true
+}
+
+// store caches the given zones, unless the cache has been invalidated since
+// the generation the zones were loaded in.
+func (c *zoneCache) store(generation uint64, zones []*Zone) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+
+	c.zones = zones
+	c.fetchedAt = time.Now()
+	c.valid = true
+	c.seeded = false
+}
+
+// seed caches zones loaded from the state file.
+func (c *zoneCache) seed(zones []*Zone, fetchedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.zones = zones
+	c.fetchedAt = fetchedAt
+	c.valid = true
+	c.seeded = true
+}
+
+// seededZones returns the zones loaded from the state file along with their
+// age, as long as they have not been refreshed yet and are not older than
+// maxAge.
+func (c *zoneCache) seededZones(maxAge time.Duration) ([]*Zone, time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if !c.seeded {
+		return nil, 0, false
+	}
+
+	age := time.Since(c.fetchedAt)
+	if age > maxAge {
+		return nil, age, false
+	}
+
+	return c.zones, age, true
+}
+
+// endSeed stops serving the zones loaded from the state file.
+func (c *zoneCache) endSeed() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.seeded = false
+}
+
+// current returns the cached zones and when they were loaded, whether or not
+// they are still fresh or valid.
+func (c *zoneCache) current() ([]*Zone, time.Time) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.zones, c.fetchedAt
+}
+
+// age returns the time since the zones were last loaded from the API.
+func (c *zoneCache) age() time.Duration {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.fetchedAt.IsZero() {
+		return 0
+	}
+
+	return time.Since(c.fetchedAt)
+}
+
+// lastKnown returns the zones last loaded from the API along with their age,
+// whether or not they are still fresh or valid, as long as they are not
+// older than maxAge.
+func (c *zoneCache) lastKnown(maxAge time.Duration) ([]*Zone, time.Duration, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	if c.zones == nil {
+		return nil, 0, false
+	}
+
+	age := time.Since(c.fetchedAt)
+	if age > maxAge {
+		return nil, age, false
+	}
+
+	return c.zones, age, true
+}
+
+// invalidate marks the cached zones as outdated, so the next read loads them
+// again.
+func (c *zoneCache) invalidate() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.valid = false
+	c.seeded = false
+	c.generation++
+}
+
+// apply updates the cached zones with the given applied changes, so reads
+// following a batch see its result without loading the zones again. The
+// freshness of the cache is left unchanged. Cached zones are shared with
+// earlier readers, so the affected zones are copied rather than modified.
+func (c *zoneCache) apply(changes []*appliedChange) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Loads that started before the changes were applied must not
+	// overwrite them.
+	c.generation++
+
+	if c.zones == nil {
+		return
+	}
+
+	byZone := make(map[int64][]*appliedChange)
+	for _, change := range changes {
+		byZone[change.ZoneID] = append(byZone[change.ZoneID], change)
+	}
+
+	zones := make([]*Zone, len(c.zones))
+	for i, zone := range c.zones {
+		zoneChanges, ok := byZone[zone.ID]
+		if !ok {
+			zones[i] = zone
+			continue
+		}
+
+		updated := *zone
+		updated.Records = applyToRecords(zone.Records, zoneChanges)
+		zones[i] = &updated
+	}
+
+	c.zones = zones
+}
+
+// applyToRecords returns a copy of the records with the changes applied.
+func applyToRecords(records []*Record, changes []*appliedChange) []*Record {
+	updated := slices.Clone(records)
+
+	for _, change := range changes {
+		byID := func(record *Record) bool { return record.ID == change.RecordID }
+
+		switch change.Kind {
+		case ChangeKindCreate:
+			updated = append(updated, change.Record)
+		case ChangeKindUpdate:
+			if i := slices.IndexFunc(updated, byID); i >= 0 {
+				updated[i] = change.Record
+			} else {
+				updated = append(updated, change.Record)
+			}
+		case ChangeKindDelete:
+			updated = slices.DeleteFunc(updated, byID)
+		}
+	}
+
+	return updated
+}
+
+func (c *zoneCache) currentGeneration() uint64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	return c.generation
+}
+
+// zones returns the zones of the account, served from the cache while it is
+// fresh and loaded from the API otherwise.
+func (p *Provider) zones(ctx context.Context) ([]*Zone, error) {
+	if zones, ok := p.cache.get(); ok {
+		slog.DebugContext(ctx, "Using cached zones.")
+		zoneCacheRequests.WithLabelValues("hit").Inc()
+
+		return zones, nil
+	}
+
+	zoneCacheRequests.WithLabelValues("miss").Inc()
+
+	return p.fetchZones(ctx)
+}
+
+// recordZones returns the zones the records returned to external-dns are read
+// from. If the zones cannot be loaded, the last known zones are served
+// instead for up to MaxStaleness, so an outage of the API does not fail
+// every external-dns loop. Zones loaded from the state file are served the
+// same way until they have been refreshed. Changes are never applied to
+// stale zones.
+func (p *Provider) recordZones(ctx context.Context) ([]*Zone, error) {
+	if p.Options.MaxStaleness > 0 {
+		if zones, age, ok := p.cache.seededZones(p.Options.MaxStaleness); ok {
+			slog.DebugContext(ctx, "Serving zones loaded from the state file.",
+				slog.Duration("age", age.Round(time.Second)))
+
+			p.stale.Store(true)
+			recordsAge.Set(age.Seconds())
+			staleRecordsServed.Inc()
+
+			return zones, nil
+		}
+	}
+
+	zones, err := p.zones(ctx)
+	if err == nil {
+		p.stale.Store(false)
+		recordsAge.Set(p.cache.age().Seconds())
+
+		return zones, nil
+	}
+
+	if p.Options.MaxStaleness <= 0 {
+		return nil, err
+	}
+
+	zones, age, ok := p.cache.lastKnown(p.Options.MaxStaleness)
+	if !ok {
+		p.stale.Store(false)
+
+		return nil, err
+	}
+
+	slog.WarnContext(ctx, "Failed to fetch zones, serving the last known records.",
+		slog.Duration("age", age.Round(time.Second)),
+		slog.Duration("max_staleness", p.Options.MaxStaleness),
+		slog.Any("error", err))
+
+	p.stale.Store(true)
+	recordsAge.Set(age.Seconds())
+	staleRecordsServed.Inc()
+
+	return zones, nil
+}
+
+// Degraded reports whether Records is serving stale zones because the API
+// could not be reached, along with their age.
+func (p *Provider) Degraded() (bool, string) {
+	if !p.stale.Load() {
+		return false, ""
+	}
+
+	return true, fmt.Sprintf("serving records fetched %s ago", p.cache.age().Round(time.Second))
+}
+
+// fetchZones loads the zones of the account from the API and refreshes the
+// cache. Concurrent loads are deduplicated, and callers that need the live
+// state of the account use it instead of zones.
+func (p *Provider) fetchZones(ctx context.Context) ([]*Zone, error) {
+	generation := p.cache.currentGeneration()
+
+	// Loads are keyed by generation, so a caller arriving after an
+	// invalidation never joins a load that started before it.
+	results := p.cache.group.DoChan(strconv.FormatUint(generation, 10), func() (any, error) {
+		// The load is shared by every caller waiting for it, so it must
+		// not be cancelled along with the caller that happened to start it.
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), zoneLoadTimeout)
+		defer cancel()
+
+		start := time.Now()
+
+		zones, err := p.listZones(ctx)
+		if err != nil {
+			zoneLoadDuration.WithLabelValues("error").Observe(time.Since(start).Seconds())
+
+			return nil, err
+		}
+
+		zoneLoadDuration.WithLabelValues("success").Observe(time.Since(start).Seconds())
+
+		p.confirmApplied(zones, start)
+		p.cache.store(generation, zones)
+		p.persistZones()
+
+		return zones, nil
+	})
+
+	// Callers stop waiting when they are cancelled, while the load carries
+	// on for the others.
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case result := <-results:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+
+		return result.Val.([]*Zone), nil
+	}
+}
+
+// updateCachedZones writes the changes applied by a batch through to the
+// cached zones. If any change failed, whether it reached Bunny.net is
+// unknown, so the cached zones are dropped instead.
+func (p *Provider) updateCachedZones(applied []*appliedChange, outcomes []ChangeOutcome) {
+	for _, outcome := range outcomes {
+		if outcome.Err != nil && !errors.Is(outcome.Err, errUnmanagedRecord) {
+			p.invalidateZones()
+			return
+		}
+	}
+
+	p.cache.apply(applied)
+	p.persistZones()
+}
+
+// invalidateZones drops the cached zones after the account has been changed.
+func (p *Provider) invalidateZones() {
+	p.cache.invalidate()
+}
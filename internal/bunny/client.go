@@ -5,14 +5,35 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"log/slog"
 	"net/http"
 	"net/url"
 	"strconv"
+	"time"
 
 	"github.com/samber/oops"
 )
 
+// defaultTTLSeconds is the TTL of records created without one. Default to 5 minutes, chosen
+// because this is the default in the Bunny.net UI.
+const defaultTTLSeconds = 5 * 60
+
+// APIError is the error returned by the Bunny.net API for unexpected responses.
+type APIError struct {
+	StatusCode int
+	ErrorKey   string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("bunny.net API returned status %d", e.StatusCode)
+	}
+
+	return fmt.Sprintf("bunny.net API returned status %d: %s (%s)", e.StatusCode, e.Message, e.ErrorKey)
+}
+
 type HTTPDoer interface {
 	Do(req *http.Request) (*http.Response, error)
 }
@@ -81,7 +102,7 @@ func (c *BunnyClient) ListZones(ctx context.Context, r ListZonesRequest) (*ListZ
 		return nil, errs.Wrapf(err, "failed to create request")
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req, "ListZones")
 	if err != nil {
 		return nil, errs.Wrapf(err, "failed to execute request")
 	}
@@ -101,18 +122,27 @@ func (c *BunnyClient) ListZones(ctx context.Context, r ListZonesRequest) (*ListZ
 }
 
 type CreateRecordRequest struct {
-	Type        RecordType  `json:"Type"`
-	TTLSeconds  int         `json:"Ttl"`
-	Value       string      `json:"Value"`
-	Name        string      `json:"Name"`
-	MonitorType MonitorType `json:"MonitorType"`
-	Weight      int         `json:"Weight"`
-	Disabled    bool        `json:"Disabled"`
+	Type                 RecordType            `json:"Type"`
+	TTLSeconds           int                   `json:"Ttl"`
+	Value                string                `json:"Value"`
+	Name                 string                `json:"Name"`
+	MonitorType          MonitorType           `json:"MonitorType"`
+	Weight               int                   `json:"Weight"`
+	Disabled             bool                  `json:"Disabled"`
+	RedirectStatusCode   int                   `json:"RedirectStatusCode,omitempty"`
+	RedirectPreservePath bool                  `json:"RedirectPreservePath,omitempty"`
+	ScriptID             int64                 `json:"ScriptId,omitempty"`
+	EnvironmentVariables []EnvironmentVariable `json:"EnviromentalVariables,omitempty"`
+	Comment              string                `json:"Comment,omitempty"`
+	Priority             int                   `json:"Priority,omitempty"`
+	Port                 int                   `json:"Port,omitempty"`
+	Flags                int                   `json:"Flags,omitempty"`
+	Tag                  string                `json:"Tag,omitempty"`
 }
 
 func (c *BunnyClient) CreateRecord(ctx context.Context, zoneID string, r CreateRecordRequest) (*Record, error) {
-	if r.TTLSeconds == 0 { // Default to 5 minutes, chosen because this is the default in the Bunny.net UI
-		r.TTLSeconds = 5 * 60 // 5 minutes
+	if r.TTLSeconds == 0 {
+		r.TTLSeconds = defaultTTLSeconds
 	}
 
 	errs := oops.In("BunnyClient").
@@ -124,6 +154,10 @@ func (c *BunnyClient) CreateRecord(ctx context.Context, zoneID string, r CreateR
 		With("monitor_type", r.MonitorType).
 		With("weight", r.Weight).
 		With("disabled", r.Disabled).
+		With("redirect_status_code", r.RedirectStatusCode).
+		With("redirect_preserve_path", r.RedirectPreservePath).
+		With("script_id", r.ScriptID).
+		With("comment", r.Comment).
 		Span("CreateRecord")
 
 	req, err := c.createRequestWithBody(ctx, http.MethodPut, fmt.Sprintf("/dnszone/%s/records", zoneID), r)
@@ -131,7 +165,7 @@ func (c *BunnyClient) CreateRecord(ctx context.Context, zoneID string, r CreateR
 		return nil, errs.Wrapf(err, "failed to create request")
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req, "CreateRecord")
 	if err != nil {
 		return nil, errs.Wrapf(err, "failed to send request")
 	}
@@ -161,7 +195,7 @@ func (c *BunnyClient) DeleteRecord(ctx context.Context, zoneID int64, recordID i
 		return errs.Wrapf(err, "failed to create request")
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req, "DeleteRecord")
 	if err != nil {
 		return errs.Wrapf(err, "failed to send request")
 	}
@@ -175,12 +209,24 @@ func (c *BunnyClient) DeleteRecord(ctx context.Context, zoneID int64, recordID i
 	return nil
 }
 
+// UpdateRecordRequest updates an existing record. Fields that can be turned
+// off or cleared are always sent, as omitting them keeps their current value.
 type UpdateRecordRequest struct {
-	TTLSeconds  int         `json:"Ttl"`
-	Value       string      `json:"Value"`
-	MonitorType MonitorType `json:"MonitorType"`
-	Weight      int         `json:"Weight"`
-	Disabled    bool        `json:"Disabled"`
+	Type                 RecordType            `json:"Type"`
+	TTLSeconds           int                   `json:"Ttl"`
+	Value                string                `json:"Value"`
+	MonitorType          MonitorType           `json:"MonitorType"`
+	Weight               int                   `json:"Weight"`
+	Disabled             bool                  `json:"Disabled"`
+	RedirectStatusCode   int                   `json:"RedirectStatusCode,omitempty"`
+	RedirectPreservePath bool                  `json:"RedirectPreservePath"`
+	ScriptID             int64                 `json:"ScriptId,omitempty"`
+	EnvironmentVariables []EnvironmentVariable `json:"EnviromentalVariables"`
+	Comment              string                `json:"Comment"`
+	Priority             int                   `json:"Priority,omitempty"`
+	Port                 int                   `json:"Port,omitempty"`
+	Flags                int                   `json:"Flags,omitempty"`
+	Tag                  string                `json:"Tag,omitempty"`
 }
 
 func (c *BunnyClient) UpdateRecord(ctx context.Context, zoneID int64, recordID int64, r UpdateRecordRequest) error {
@@ -191,15 +237,26 @@ func (c *BunnyClient) UpdateRecord(ctx context.Context, zoneID int64, recordID i
 		With("updatedValue", r.Value).
 		With("updatedMonitorType", r.MonitorType).
 		With("updatedWeight", r.Weight).
+		With("updatedType", r.Type).
 		With("updatedDisabled", r.Disabled).
+		With("updatedRedirectStatusCode", r.RedirectStatusCode).
+		With("updatedRedirectPreservePath", r.RedirectPreservePath).
+		With("updatedScriptID", r.ScriptID).
+		With("updatedComment", r.Comment).
 		Span("UpdateRecord")
 
+	// An empty list clears the environment variables, while null would
+	// keep them.
+	if r.EnvironmentVariables == nil {
+		r.EnvironmentVariables = []EnvironmentVariable{}
+	}
+
 	req, err := c.createRequestWithBody(ctx, http.MethodPost, fmt.Sprintf("/dnszone/%d/records/%d", zoneID, recordID), r)
 	if err != nil {
 		return errs.Wrapf(err, "failed to create request")
 	}
 
-	resp, err := c.client.Do(req)
+	resp, err := c.do(req, "UpdateRecord")
 	if err != nil {
 		return errs.Wrapf(err, "failed to send request")
 	}
@@ -213,6 +270,89 @@ func (c *BunnyClient) UpdateRecord(ctx context.Context, zoneID int64, recordID i
 	return nil
 }
 
+const (
+	// maxRateLimitRetries is the number of times a request rejected by the
+	// rate limits of the API is retried before the rejection is returned.
+	maxRateLimitRetries = 5
+	// rateLimitBackoff is the wait before the first retry of a rate limited
+	// request without a Retry-After header. It doubles with every retry.
+	rateLimitBackoff = time.Second
+	// maxRateLimitBackoff caps the wait between retries without a
+	// Retry-After header.
+	maxRateLimitBackoff = 30 * time.Second
+)
+
+// do executes the request, recording the outcome and latency of every call to
+// the given API method. Requests rejected by the rate limits of the API are
+// retried after the wait requested in the Retry-After header, or with an
+// exponential backoff if there is none.
+func (c *BunnyClient) do(req *http.Request, method string) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := c.send(req, method)
+		if err != nil || resp.StatusCode != http.StatusTooManyRequests || attempt == maxRateLimitRetries {
+			return resp, err
+		}
+
+		wait := retryAfter(resp.Header.Get("Retry-After"), attempt)
+
+		_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+		resp.Body.Close()
+
+		slog.DebugContext(req.Context(), "Request was rate limited, retrying.",
+			slog.String("method", method),
+			slog.Int("attempt", attempt+1),
+			slog.Duration("wait", wait))
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-req.Context().Done():
+			timer.Stop()
+
+			return nil, req.Context().Err()
+		case <-timer.C:
+		}
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, fmt.Errorf("failed to rewind request body: %w", err)
+			}
+
+			req.Body = body
+		}
+	}
+}
+
+func (c *BunnyClient) send(req *http.Request, method string) (*http.Response, error) {
+	start := time.Now()
+
+	resp, err := c.client.Do(req)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	apiRequests.WithLabelValues(method, status).Inc()
+	apiRequestDuration.WithLabelValues(method).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
+
+// retryAfter returns the wait before the given retry of a rate limited
+// request. The Retry-After header is either a number of seconds or a date.
+func retryAfter(header string, attempt int) time.Duration {
+	if seconds, err := strconv.Atoi(header); err == nil && seconds >= 0 {
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(header); err == nil {
+		return max(time.Until(date), 0)
+	}
+
+	return min(rateLimitBackoff<<attempt, maxRateLimitBackoff)
+}
+
 func (c *BunnyClient) createRequest(ctx context.Context, method string, path string, query url.Values) (*http.Request, error) {
 	url := &url.URL{
 		Scheme:   "https",
@@ -262,10 +402,14 @@ func handleUnexpectedResponse(errBuilder oops.OopsErrorBuilder, resp *http.Respo
 	//nolint:errcheck // This is already an error path, so we don't care about the error here.
 	json.NewDecoder(resp.Body).Decode(&errBody)
 
+	apiErr := &APIError{StatusCode: resp.StatusCode}
+	apiErr.ErrorKey, _ = errBody["ErrorKey"].(string)
+	apiErr.Message, _ = errBody["Message"].(string)
+
 	err := errBuilder.
 		With("status", resp.Status).
 		With("statusCode", resp.StatusCode).
-		Errorf("unexpected status code: %d", resp.StatusCode)
+		Wrapf(apiErr, "unexpected status code: %d", resp.StatusCode)
 
 	slog.Error("Received an unexpected response from Bunny.net.",
 		slog.Any("error", err),
@@ -0,0 +1,145 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// recordingDoer answers every request with status, after rejecting the first
+// limited requests with 429 Too Many Requests. It records the body of the last
+// request.
+type recordingDoer struct {
+	status   int
+	limited  int
+	requests int
+	body     []byte
+}
+
+func (d *recordingDoer) Do(req *http.Request) (*http.Response, error) {
+	d.requests++
+
+	if req.Body != nil {
+		body, err := io.ReadAll(req.Body)
+		if err != nil {
+			return nil, err
+		}
+
+		d.body = body
+	}
+
+	status := d.status
+	header := http.Header{}
+	if d.requests <= d.limited {
+		status = http.StatusTooManyRequests
+		header.Set("Retry-After", "0")
+	}
+
+	return &http.Response{
+		StatusCode: status,
+		Body:       io.NopCloser(strings.NewReader("")),
+		Header:     header,
+	}, nil
+}
+
+func TestUpdateRecordSendsClearedFields(t *testing.T) {
+	doer := &recordingDoer{status: http.StatusNoContent}
+	client := NewDNSClient(doer, "key")
+
+	err := client.UpdateRecord(context.Background(), 1, 2, UpdateRecordRequest{
+		Type:  RecordTypeSCR,
+		Value: "",
+	})
+	if err != nil {
+		t.Fatalf("UpdateRecord() error = %v", err)
+	}
+
+	var body map[string]any
+	if err := json.Unmarshal(doer.body, &body); err != nil {
+		t.Fatalf("failed to decode request body: %v", err)
+	}
+
+	tests := map[string]any{
+		"RedirectPreservePath":  false,
+		"Comment":               "",
+		"EnviromentalVariables": []any{},
+	}
+
+	for field, want := range tests {
+		got, ok := body[field]
+		if !ok {
+			t.Errorf("request body is missing %s", field)
+			continue
+		}
+
+		gotJSON, _ := json.Marshal(got)
+		wantJSON, _ := json.Marshal(want)
+		if string(gotJSON) != string(wantJSON) {
+			t.Errorf("%s = %s, want %s", field, gotJSON, wantJSON)
+		}
+	}
+}
+
+func TestRateLimitedRequestsAreRetried(t *testing.T) {
+	tests := []struct {
+		name     string
+		limited  int
+		requests int
+		wantErr  bool
+	}{
+		{name: "not limited", limited: 0, requests: 1},
+		{name: "limited twice", limited: 2, requests: 3},
+		{name: "retries exhausted", limited: maxRateLimitRetries + 1, requests: maxRateLimitRetries + 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doer := &recordingDoer{status: http.StatusNoContent, limited: tt.limited}
+			client := NewDNSClient(doer, "key")
+
+			err := client.UpdateRecord(context.Background(), 1, 2, UpdateRecordRequest{Type: RecordTypeA, Value: "1.1.1.1"})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UpdateRecord() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if doer.requests != tt.requests {
+				t.Errorf("sent %d requests, want %d", doer.requests, tt.requests)
+			}
+
+			// Every retry must send the full body again.
+			var body UpdateRecordRequest
+			if err := json.Unmarshal(doer.body, &body); err != nil {
+				t.Fatalf("failed to decode request body: %v", err)
+			}
+
+			if body.Value != "1.1.1.1" {
+				t.Errorf("Value = %q, want %q", body.Value, "1.1.1.1")
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		header  string
+		attempt int
+		want    time.Duration
+	}{
+		{header: "3", want: 3 * time.Second},
+		{header: "", attempt: 0, want: rateLimitBackoff},
+		{header: "", attempt: 2, want: 4 * rateLimitBackoff},
+		{header: "", attempt: 10, want: maxRateLimitBackoff},
+		{header: "invalid", attempt: 1, want: 2 * rateLimitBackoff},
+		{header: "Mon, 02 Jan 2006 15:04:05 GMT", want: 0},
+	}
+
+	for _, tt := range tests {
+		if got := retryAfter(tt.header, tt.attempt); got != tt.want {
+			t.Errorf("retryAfter(%q, %d) = %s, want %s", tt.header, tt.attempt, got, tt.want)
+		}
+	}
+}
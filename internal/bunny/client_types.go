@@ -85,23 +85,54 @@ func MonitorTypeFromString(s string) MonitorType {
 	}
 }
 
+// MonitorStatus is an enum for the health of a monitored record as reported
+// by Bunny.net.
+type MonitorStatus int
+
+const (
+	MonitorStatusUnknown MonitorStatus = iota
+	MonitorStatusOnline
+	MonitorStatusOffline
+)
+
+func (m MonitorStatus) String() string {
+	if m < MonitorStatusUnknown || m > MonitorStatusOffline {
+		return "unknown"
+	}
+
+	return [...]string{"unknown", "online", "offline"}[m]
+}
+
 type Record struct {
-	ID                    int64       `json:"Id"`
-	Type                  RecordType  `json:"Type"`
-	TTLSeconds            int         `json:"Ttl"`
-	Value                 string      `json:"Value"`
-	Name                  string      `json:"Name"`
-	Weight                int         `json:"Weight"`
-	Priority              int         `json:"Priority"`
-	Port                  int         `json:"Port"`
-	Flags                 int         `json:"Flags"`
-	Tag                   string      `json:"Tag"`
-	MonitorType           MonitorType `json:"MonitorType"`
-	Accelerated           bool        `json:"Accelerated"`
-	AcceleratedPullZoneID int64       `json:"AcceleratedPullZoneId"`
-	LinkName              string      `json:"LinkName"`
-	Disabled              bool        `json:"Disabled"`
-	Comment               string      `json:"Comment"`
+	ID                    int64         `json:"Id"`
+	Type                  RecordType    `json:"Type"`
+	TTLSeconds            int           `json:"Ttl"`
+	Value                 string        `json:"Value"`
+	Name                  string        `json:"Name"`
+	Weight                int           `json:"Weight"`
+	Priority              int           `json:"Priority"`
+	Port                  int           `json:"Port"`
+	Flags                 int           `json:"Flags"`
+	Tag                   string        `json:"Tag"`
+	MonitorType           MonitorType   `json:"MonitorType"`
+	MonitorStatus         MonitorStatus `json:"MonitorStatus"`
+	Accelerated           bool          `json:"Accelerated"`
+	AcceleratedPullZoneID int64         `json:"AcceleratedPullZoneId"`
+	LinkName              string        `json:"LinkName"`
+	Disabled              bool          `json:"Disabled"`
+	Comment               string        `json:"Comment"`
+	RedirectStatusCode    int           `json:"RedirectStatusCode"`
+	RedirectPreservePath  bool          `json:"RedirectPreservePath"`
+	ScriptID              int64         `json:"ScriptId"`
+	// EnvironmentVariables is spelled the way the Bunny.net API spells it.
+	EnvironmentVariables []EnvironmentVariable `json:"EnviromentalVariables"`
+}
+
+// EnvironmentVariable is a variable passed to the script bound to a
+// scriptable DNS record.
+type EnvironmentVariable struct {
+	Name  string `json:"Name"`
+	Value string `json:"Value"`
 }
 
 type Zone struct {
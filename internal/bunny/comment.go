@@ -0,0 +1,155 @@
+package bunny
+
+import (
+	"maps"
+	"strings"
+	"text/template"
+	"time"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// commentData is the data available to the default comment template.
+type commentData struct {
+	// Owner is the external-dns owner ID of the record, if known.
+	Owner string
+	// Resource is the Kubernetes resource the record originates from,
+	// e.g. ingress/default/my-ingress, if known.
+	Resource string
+	// DNSName is the fully qualified name of the record.
+	DNSName string
+	// RecordType is the Bunny.net type the record is stored as.
+	RecordType string
+	// CreatedAt is the time the record was created by the provider,
+	// formatted as RFC 3339. The template is only rendered for records
+	// without a comment, so the time is kept when the record is updated.
+	CreatedAt string
+}
+
+// parseCommentTemplate parses the default comment template. An empty template
+// results in a nil template, which disables default comments.
+func parseCommentTemplate(text string) (*template.Template, error) {
+	if text == "" {
+		return nil, nil
+	}
+
+	return template.New("comment").Parse(text)
+}
+
+// recordComment returns the comment to store on the record for the given
+// endpoint. An explicit comment set through the provider-specific property
+// takes precedence over the default comment template. When records are
+// tagged, the labels of the endpoint are appended to the comment.
+func (p *Provider) recordComment(ep *endpoint.Endpoint, recordType RecordType, opts providerSpecificOptions) (string, error) {
+	comment, err := p.renderComment(ep, recordType, opts)
+	if err != nil {
+		return "", err
+	}
+
+	if p.tagsRecords() {
+		comment = joinComment(comment, registryLabels(ep))
+	}
+
+	return comment, nil
+}
+
+func (p *Provider) renderComment(ep *endpoint.Endpoint, recordType RecordType, opts providerSpecificOptions) (string, error) {
+	if opts.Comment != "" || p.commentTemplate == nil {
+		return opts.Comment, nil
+	}
+
+	data := commentData{
+		Owner:      ep.Labels[endpoint.OwnerLabelKey],
+		Resource:   ep.Labels[endpoint.ResourceLabelKey],
+		DNSName:    ep.DNSName,
+		RecordType: recordType.String(),
+		CreatedAt:  time.Now().UTC().Format(time.RFC3339),
+	}
+
+	var b strings.Builder
+	if err := p.commentTemplate.Execute(&b, data); err != nil {
+		return "", err
+	}
+
+	return b.String(), nil
+}
+
+// existingComment returns the human readable part of the comment of an
+// existing record, which is kept when the record is updated without an
+// explicit comment.
+func existingComment(record *Record) string {
+	if record == nil {
+		return ""
+	}
+
+	comment, _, _ := splitComment(record.Comment)
+
+	return comment
+}
+
+// registryLabels returns the labels of the endpoint that are persisted in the
+// comment registry.
+func registryLabels(ep *endpoint.Endpoint) endpoint.Labels {
+	labels := endpoint.NewLabels()
+	maps.Copy(labels, ep.Labels)
+
+	// The monitor status is reported by Bunny.net and is not ours to store.
+	delete(labels, labelMonitorStatus)
+
+	return labels
+}
+
+// joinComment appends the serialized labels to a human readable comment.
+func joinComment(comment string, labels endpoint.Labels) string {
+	serialized := labels.SerializePlain(false)
+	if comment == "" {
+		return serialized
+	}
+
+	return comment + " " + serialized
+}
+
+// splitComment splits a record comment into its human readable part and the
+// labels appended by joinComment. The returned boolean is false if the
+// comment carries no labels, i.e. the record was not written by the comment
+// registry.
+func splitComment(comment string) (string, endpoint.Labels, bool) {
+	idx := strings.Index(comment, "heritage=")
+	if idx < 0 {
+		return comment, nil, false
+	}
+
+	labels, err := endpoint.NewLabelsFromStringPlain(comment[idx:])
+	if err != nil {
+		return comment, nil, false
+	}
+
+	return strings.TrimSpace(comment[:idx]), labels, true
+}
+
+// isRegistryRecord reports whether the endpoint is a TXT record written by the
+// external-dns TXT registry.
+func isRegistryRecord(ep *endpoint.Endpoint) bool {
+	if ep.RecordType != endpoint.RecordTypeTXT || len(ep.Targets) == 0 {
+		return false
+	}
+
+	_, err := endpoint.NewLabelsFromStringPlain(ep.Targets[0])
+
+	return err == nil
+}
+
+// withoutRegistryRecords returns the endpoints that are not TXT registry
+// records.
+func withoutRegistryRecords(endpoints []*endpoint.Endpoint) []*endpoint.Endpoint {
+	var filtered []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		if isRegistryRecord(ep) {
+			continue
+		}
+
+		filtered = append(filtered, ep)
+	}
+
+	return filtered
+}
@@ -0,0 +1,261 @@
+package bunny
+
+import (
+	"context"
+	"log/slog"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/samber/oops"
+)
+
+// appliedRecord is the state the provider last applied to a record, as
+// returned by the API.
+type appliedRecord struct {
+	ZoneID int64   `json:"zone_id"`
+	Record *Record `json:"record"`
+
+	// Pending is set for updates, as the API does not return the updated
+	// record. Their state is taken from the first zones loaded after
+	// AppliedAt, and they are not checked for drift until then.
+	Pending   bool      `json:"pending,omitempty"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// Drift describes a record whose state in Bunny.net differs from the state
+// the provider last applied to it.
+type Drift struct {
+	ZoneID int64
+	Zone   string
+	// Expected is the record as last applied by the provider.
+	Expected *Record
+	// Actual is the record as found in Bunny.net, nil if it was deleted.
+	Actual *Record
+	// Reverted reports whether the record was reverted to the expected
+	// state.
+	Reverted bool
+	// Err is the error that prevented the record from being reverted.
+	Err error
+}
+
+// trackApplied remembers the state of the records after the given changes
+// were applied, which is the state drift is detected against.
+func (p *Provider) trackApplied(changes []*appliedChange) {
+	if len(changes) == 0 {
+		return
+	}
+
+	now := time.Now()
+	for _, change := range changes {
+		if change.Record == nil {
+			p.lastApplied.Delete(change.RecordID)
+			continue
+		}
+
+		p.lastApplied.Store(change.RecordID, appliedRecord{
+			ZoneID:    change.ZoneID,
+			Record:    change.Record,
+			Pending:   change.Kind == ChangeKindUpdate,
+			AppliedAt: now,
+		})
+	}
+
+	p.persistZones()
+}
+
+// confirmApplied takes the state of records pending confirmation from the
+// given zones, if the zones were loaded after the records were applied.
+// Records that no longer exist keep the state requested by the provider, so
+// they are reported as deleted.
+func (p *Provider) confirmApplied(zones []*Zone, loadedAt time.Time) {
+	records := make(map[int64]*Record)
+	for _, zone := range zones {
+		for _, record := range zone.Records {
+			records[record.ID] = record
+		}
+	}
+
+	p.lastApplied.Range(func(id int64, applied appliedRecord) bool {
+		if !applied.Pending || !loadedAt.After(applied.AppliedAt) {
+			return true
+		}
+
+		if record, ok := records[id]; ok {
+			applied.Record = record
+		}
+
+		applied.Pending = false
+		p.lastApplied.Store(id, applied)
+
+		return true
+	})
+}
+
+// untrackApplied forgets the records touched by the given changes, e.g.
+// because the changes have been rolled back.
+func (p *Provider) untrackApplied(changes []*appliedChange) {
+	for _, change := range changes {
+		p.lastApplied.Delete(change.RecordID)
+	}
+
+	p.persistZones()
+}
+
+// DetectDrift compares the records the provider has applied changes to with
+// their current state in Bunny.net and returns the records that drifted. If
+// revert is set, drifted records are changed back to the state last applied
+// by the provider, unless the provider is in dry-run mode.
+func (p *Provider) DetectDrift(ctx context.Context, revert bool) ([]Drift, error) {
+	errs := oops.In("Provider").
+		Span("DetectDrift")
+
+	revert = revert && !p.Options.DryRun
+
+	// Reverts are changes like any other, and must not interleave with a
+	// batch applied at the same time.
+	if revert {
+		p.applyMu.Lock()
+		defer p.applyMu.Unlock()
+	}
+
+	zones, err := p.fetchZones(ctx)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to fetch zones")
+	}
+
+	domains := make(map[int64]string)
+	records := make(map[int64]*Record)
+	bySlot := make(map[recordSlot][]*Record)
+	for _, zone := range zones {
+		domains[zone.ID] = zone.Domain
+
+		for _, record := range zone.Records {
+			records[record.ID] = record
+			bySlot[slotOf(zone.ID, record)] = append(bySlot[slotOf(zone.ID, record)], record)
+		}
+	}
+
+	// The tracked records are copied before they are checked, as reverts
+	// track the records they recreate.
+	tracked := make(map[int64]appliedRecord)
+	served := make(map[recordSlot]bool)
+	p.lastApplied.Range(func(id int64, applied appliedRecord) bool {
+		tracked[id] = applied
+		if _, ok := records[id]; ok {
+			served[slotOf(applied.ZoneID, applied.Record)] = true
+		}
+
+		return true
+	})
+
+	var drifts []Drift
+	for _, id := range slices.Sorted(maps.Keys(tracked)) {
+		applied := tracked[id]
+		if applied.Pending {
+			slog.DebugContext(ctx, "Skipping record applied after the zones were loaded.",
+				slog.Int64("zone_id", applied.ZoneID),
+				slog.Int64("record_id", id))
+
+			continue
+		}
+
+		actual, ok := records[id]
+		if ok && recordsEqual(actual, applied.Record) {
+			continue
+		}
+
+		// A deleted record may have been recreated under a new ID, for
+		// example by external-dns after it was deleted in the dashboard.
+		if !ok && p.rekeyApplied(id, applied, bySlot[slotOf(applied.ZoneID, applied.Record)], served) {
+			continue
+		}
+
+		drift := Drift{
+			ZoneID:   applied.ZoneID,
+			Zone:     domains[applied.ZoneID],
+			Expected: applied.Record,
+			Actual:   actual,
+		}
+
+		if revert {
+			drift.Err = p.revertDrift(ctx, &drift)
+			drift.Reverted = drift.Err == nil
+		}
+
+		drifts = append(drifts, drift)
+	}
+
+	return drifts, nil
+}
+
+// recordSlot identifies the records sharing a name and type in a zone.
+type recordSlot struct {
+	ZoneID int64
+	Name   string
+	Type   RecordType
+}
+
+func slotOf(zoneID int64, record *Record) recordSlot {
+	return recordSlot{ZoneID: zoneID, Name: record.Name, Type: record.Type}
+}
+
+// rekeyApplied handles a tracked record that no longer exists under its ID.
+// If a record with the same name, type and value exists, the record is
+// tracked under the ID of that record instead. If the name and type are
+// served by another tracked record, the record is no longer tracked. It
+// reports whether the record was handled, or has to be treated as deleted.
+func (p *Provider) rekeyApplied(id int64, applied appliedRecord, candidates []*Record, served map[recordSlot]bool) bool {
+	for _, candidate := range candidates {
+		if !sameRecordValue(candidate, createRequestFromRecord(applied.Record)) {
+			continue
+		}
+
+		p.lastApplied.Delete(id)
+		if _, ok := p.lastApplied.Load(candidate.ID); !ok {
+			p.lastApplied.Store(candidate.ID, appliedRecord{ZoneID: applied.ZoneID, Record: candidate, AppliedAt: applied.AppliedAt})
+		}
+
+		p.persistZones()
+
+		return true
+	}
+
+	if served[slotOf(applied.ZoneID, applied.Record)] {
+		p.lastApplied.Delete(id)
+		p.persistZones()
+
+		return true
+	}
+
+	return false
+}
+
+// revertDrift changes a drifted record back to the state last applied by the
+// provider. Deleted records are recreated and tracked under their new ID. The
+// caller must hold the apply lock.
+func (p *Provider) revertDrift(ctx context.Context, drift *Drift) error {
+	defer p.invalidateZones()
+
+	if drift.Actual != nil {
+		err := p.client.UpdateRecord(ctx, drift.ZoneID, drift.Expected.ID, updateRequestFromRecord(drift.Expected))
+		if err != nil {
+			return err
+		}
+
+		p.trackApplied([]*appliedChange{{Kind: ChangeKindUpdate, ZoneID: drift.ZoneID, RecordID: drift.Expected.ID, Previous: drift.Actual, Record: drift.Expected}})
+
+		return nil
+	}
+
+	created, err := p.client.CreateRecord(ctx, strconv.FormatInt(drift.ZoneID, 10), createRequestFromRecord(drift.Expected))
+	if err != nil {
+		return err
+	}
+
+	p.lastApplied.Delete(drift.Expected.ID)
+	p.trackApplied([]*appliedChange{{Kind: ChangeKindCreate, ZoneID: drift.ZoneID, RecordID: created.ID, Record: created}})
+
+	return nil
+}
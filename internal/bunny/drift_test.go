@@ -0,0 +1,86 @@
+package bunny
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDetectDriftDeletedRecords(t *testing.T) {
+	expected := &Record{ID: 1, Name: "www", Type: RecordTypeA, TTLSeconds: 300, Value: "1.1.1.1"}
+
+	tests := []struct {
+		name    string
+		records []*Record
+		tracked []int64
+		drifts  int
+		calls   []string
+	}{
+		{
+			name:    "recreated under a new ID",
+			records: []*Record{{ID: 7, Name: "www", Type: RecordTypeA, TTLSeconds: 300, Value: "1.1.1.1"}},
+			tracked: []int64{7},
+		},
+		{
+			name:    "served by another tracked record",
+			records: []*Record{{ID: 8, Name: "www", Type: RecordTypeA, TTLSeconds: 300, Value: "2.2.2.2"}},
+			tracked: []int64{8},
+		},
+		{
+			name:    "deleted",
+			drifts:  1,
+			calls:   []string{"create www"},
+			tracked: []int64{1001},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			zone := &Zone{ID: 1, Domain: "example.com", Records: tt.records}
+			p := newTestProvider(Options{}, zone)
+
+			applied := time.Now().Add(-time.Minute)
+			p.lastApplied.Store(1, appliedRecord{ZoneID: 1, Record: expected, AppliedAt: applied})
+			for _, record := range tt.records {
+				if record.Value != expected.Value {
+					p.lastApplied.Store(record.ID, appliedRecord{ZoneID: 1, Record: record, AppliedAt: applied})
+				}
+			}
+
+			drifts, err := p.DetectDrift(context.Background(), true)
+			if err != nil {
+				t.Fatalf("DetectDrift() error = %v", err)
+			}
+
+			if len(drifts) != tt.drifts {
+				t.Errorf("DetectDrift() returned %d drifts, want %d", len(drifts), tt.drifts)
+			}
+
+			if calls := p.client.(*fakeClient).calls; !slices.Equal(calls, tt.calls) {
+				t.Errorf("client calls = %v, want %v", calls, tt.calls)
+			}
+
+			var tracked []int64
+			p.lastApplied.Range(func(id int64, _ appliedRecord) bool {
+				tracked = append(tracked, id)
+				return true
+			})
+			slices.Sort(tracked)
+
+			if !slices.Equal(tracked, tt.tracked) {
+				t.Errorf("tracked records = %v, want %v", tracked, tt.tracked)
+			}
+
+			// A second pass must not recreate the record again.
+			drifts, err = p.DetectDrift(context.Background(), true)
+			if err != nil {
+				t.Fatalf("DetectDrift() error = %v", err)
+			}
+
+			if len(drifts) != 0 {
+				t.Errorf("second DetectDrift() returned %d drifts, want 0", len(drifts))
+			}
+		})
+	}
+}
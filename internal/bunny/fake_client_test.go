@@ -0,0 +1,161 @@
+package bunny
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+)
+
+// fakeClient is an in-memory Client. Calls are recorded in the order they
+// were made, and fail makes matching calls fail.
+type fakeClient struct {
+	mu     sync.Mutex
+	zones  []*Zone
+	nextID int64
+	calls  []string
+
+	// fail returns the error a call should fail with, if any.
+	fail func(call string) error
+	// before is called before each mutating call, outside of the lock.
+	before func(call string)
+}
+
+func newFakeClient(zones ...*Zone) *fakeClient {
+	return &fakeClient{zones: zones, nextID: 1000}
+}
+
+func (c *fakeClient) record(call string) error {
+	if c.before != nil {
+		c.before(call)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.calls = append(c.calls, call)
+
+	if c.fail != nil {
+		return c.fail(call)
+	}
+
+	return nil
+}
+
+func (c *fakeClient) zone(id int64) (*Zone, error) {
+	for _, zone := range c.zones {
+		if zone.ID == id {
+			return zone, nil
+		}
+	}
+
+	return nil, fmt.Errorf("zone %d not found", id)
+}
+
+func (c *fakeClient) ListZones(_ context.Context, _ ListZonesRequest) (*ListZonesResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	zones := make([]*Zone, 0, len(c.zones))
+	for _, zone := range c.zones {
+		copied := *zone
+		copied.Records = make([]*Record, 0, len(zone.Records))
+		for _, record := range zone.Records {
+			r := *record
+			copied.Records = append(copied.Records, &r)
+		}
+
+		zones = append(zones, &copied)
+	}
+
+	return &ListZonesResponse{Items: zones, CurrentPage: 1, TotalItems: len(zones)}, nil
+}
+
+func (c *fakeClient) CreateRecord(_ context.Context, zoneID string, r CreateRecordRequest) (*Record, error) {
+	if err := c.record("create " + r.Name); err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	id, err := strconv.ParseInt(zoneID, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+
+	zone, err := c.zone(id)
+	if err != nil {
+		return nil, err
+	}
+
+	c.nextID++
+
+	created := &Record{
+		ID:                   c.nextID,
+		Name:                 r.Name,
+		Type:                 r.Type,
+		TTLSeconds:           r.TTLSeconds,
+		Value:                r.Value,
+		MonitorType:          r.MonitorType,
+		Weight:               r.Weight,
+		Disabled:             r.Disabled,
+		RedirectStatusCode:   r.RedirectStatusCode,
+		RedirectPreservePath: r.RedirectPreservePath,
+		ScriptID:             r.ScriptID,
+		EnvironmentVariables: r.EnvironmentVariables,
+		Comment:              r.Comment,
+	}
+	zone.Records = append(zone.Records, created)
+
+	copied := *created
+
+	return &copied, nil
+}
+
+func (c *fakeClient) UpdateRecord(_ context.Context, zoneID int64, recordID int64, r UpdateRecordRequest) error {
+	if err := c.record(fmt.Sprintf("update %d", recordID)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	zone, err := c.zone(zoneID)
+	if err != nil {
+		return err
+	}
+
+	i := slices.IndexFunc(zone.Records, func(record *Record) bool { return record.ID == recordID })
+	if i < 0 {
+		return fmt.Errorf("record %d not found", recordID)
+	}
+
+	updated := *zone.Records[i]
+	updated.Type = r.Type
+	updated.TTLSeconds = r.TTLSeconds
+	updated.Value = r.Value
+	updated.Comment = r.Comment
+	zone.Records[i] = &updated
+
+	return nil
+}
+
+func (c *fakeClient) DeleteRecord(_ context.Context, zoneID int64, recordID int64) error {
+	if err := c.record(fmt.Sprintf("delete %d", recordID)); err != nil {
+		return err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	zone, err := c.zone(zoneID)
+	if err != nil {
+		return err
+	}
+
+	zone.Records = slices.DeleteFunc(zone.Records, func(record *Record) bool { return record.ID == recordID })
+
+	return nil
+}
@@ -0,0 +1,196 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"os"
+
+	"github.com/samber/oops"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+// guardrail limits the number of changes of one kind applied to a single
+// zone in one batch, either as an absolute number or as a percentage of the
+// records managed in the zone. A zero limit disables the check.
+type guardrail struct {
+	Kind    string
+	Max     int
+	Percent int
+}
+
+func (g guardrail) enabled() bool {
+	return g.Max > 0 || g.Percent > 0
+}
+
+// managedRecordCount returns the number of records in the zone that are
+// managed by external-dns. Registry records are not counted. If no managed
+// records can be identified, for example because the TXT registry is not
+// configured to match external-dns, every record in the zone is counted, so
+// the percentage limits still apply.
+func (p *Provider) managedRecordCount(zone *Zone) int {
+	var managed int
+	for _, registry := range p.managedRecords(zone) {
+		if !registry {
+			managed++
+		}
+	}
+
+	if managed > 0 {
+		return managed
+	}
+
+	for _, record := range zone.Records {
+		if ep, ok := recordToEndpoint(zone.Domain, record); ok && !isRegistryRecord(ep) {
+			managed++
+		}
+	}
+
+	return managed
+}
+
+// exceeded reports whether the given number of changes exceeds the limits for
+// a zone with the given number of managed records.
+func (g guardrail) exceeded(changes int, managed int) bool {
+	if g.Max > 0 && changes > g.Max {
+		return true
+	}
+
+	// Changes to a zone without any records to compare them to are
+	// refused rather than let through unchecked.
+	if g.Percent > 0 && changes > 0 && changes*100 > g.Percent*managed {
+		return true
+	}
+
+	return false
+}
+
+// guardrails returns the delete and update guardrails, in that order.
+func (p *Provider) guardrails() []guardrail {
+	return []guardrail{
+		{Kind: "delete", Max: p.Options.MaxDeletes, Percent: p.Options.MaxDeletesPercent},
+		{Kind: "update", Max: p.Options.MaxUpdates, Percent: p.Options.MaxUpdatesPercent},
+	}
+}
+
+// checkGuardrails refuses a batch of changes that deletes or updates more
+// records per zone than the configured thresholds allow, unless the
+// guardrails have been explicitly overridden.
+func (p *Provider) checkGuardrails(ctx context.Context, changes *plan.Changes) error {
+	errs := oops.In("Provider").
+		Span("checkGuardrails")
+
+	guardrails := p.guardrails()
+	if !guardrails[0].enabled() && !guardrails[1].enabled() {
+		return nil
+	}
+
+	if len(changes.Delete) == 0 && len(changes.UpdateNew) == 0 {
+		return nil
+	}
+
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return errs.Wrapf(err, "failed to fetch zones")
+	}
+
+	var domainNames []string
+	managed := make(map[string]int)
+	for _, zone := range zones {
+		domainNames = append(domainNames, zone.Domain)
+		managed[zone.Domain] = p.managedRecordCount(zone)
+	}
+
+	var exceeded bool
+	for i, endpoints := range [][]*endpoint.Endpoint{changes.Delete, changes.UpdateNew} {
+		guardrail := guardrails[i]
+		if !guardrail.enabled() {
+			continue
+		}
+
+		// Registry records come and go with the records they own, and
+		// are not counted as changes of their own.
+		byZone := make(map[string][]*endpoint.Endpoint)
+		for _, ep := range withoutRegistryRecords(endpoints) {
+			_, domainName, ok := extractRecordComponents(domainNames, ep.DNSName)
+			if !ok {
+				continue
+			}
+
+			byZone[domainName] = append(byZone[domainName], ep)
+		}
+
+		for zone, zoneEndpoints := range byZone {
+			if !guardrail.exceeded(len(zoneEndpoints), managed[zone]) {
+				continue
+			}
+
+			exceeded = true
+
+			slog.ErrorContext(ctx, "Change guardrail exceeded.",
+				slog.String("zone", zone),
+				slog.String("kind", guardrail.Kind),
+				slog.Int("changes", len(zoneEndpoints)),
+				slog.Int("managed", managed[zone]),
+				slog.Int("max", guardrail.Max),
+				slog.Int("max_percent", guardrail.Percent),
+			)
+
+			for _, ep := range zoneEndpoints {
+				slog.InfoContext(ctx, "Change exceeding guardrail.",
+					slog.String("zone", zone),
+					slog.String("kind", guardrail.Kind),
+					slog.Group("record",
+						slog.String("name", ep.DNSName),
+						slog.String("type", ep.RecordType),
+						slog.Any("value", ep.Targets),
+						slog.Int("ttl", int(ep.RecordTTL)),
+					))
+			}
+		}
+	}
+
+	if !exceeded {
+		return nil
+	}
+
+	overridden, err := p.consumeGuardrailOverride()
+	if err != nil {
+		return errs.Wrapf(err, "failed to consume the guardrail override")
+	}
+
+	if overridden {
+		slog.WarnContext(ctx, "Applying changes exceeding the guardrails because they have been overridden.",
+			slog.String("override_file", p.Options.GuardrailOverrideFile))
+		return nil
+	}
+
+	if p.Options.GuardrailOverrideFile == "" {
+		return errs.Errorf("changes exceed the configured guardrails")
+	}
+
+	return errs.
+		With("override_file", p.Options.GuardrailOverrideFile).
+		Errorf("changes exceed the configured guardrails, create the guardrail override file to apply them once")
+}
+
+// consumeGuardrailOverride reports whether the guardrails have been overridden
+// for the current batch. The override file is removed when it is consumed,
+// so an override only ever lets a single batch through.
+func (p *Provider) consumeGuardrailOverride() (bool, error) {
+	if p.Options.GuardrailOverrideFile == "" {
+		return false, nil
+	}
+
+	err := os.Remove(p.Options.GuardrailOverrideFile)
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+
+	if err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
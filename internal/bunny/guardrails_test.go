@@ -0,0 +1,53 @@
+package bunny
+
+import (
+	"testing"
+)
+
+func TestGuardrailExceeded(t *testing.T) {
+	tests := []struct {
+		name      string
+		guardrail guardrail
+		changes   int
+		managed   int
+		want      bool
+	}{
+		{name: "disabled", guardrail: guardrail{}, changes: 100, managed: 1, want: false},
+		{name: "below max", guardrail: guardrail{Max: 5}, changes: 5, managed: 100, want: false},
+		{name: "above max", guardrail: guardrail{Max: 5}, changes: 6, managed: 100, want: true},
+		{name: "below percent", guardrail: guardrail{Percent: 10}, changes: 10, managed: 100, want: false},
+		{name: "above percent", guardrail: guardrail{Percent: 10}, changes: 11, managed: 100, want: true},
+		{name: "no managed records", guardrail: guardrail{Percent: 10}, changes: 1, managed: 0, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.guardrail.exceeded(tt.changes, tt.managed); got != tt.want {
+				t.Errorf("exceeded(%d, %d) = %v, want %v", tt.changes, tt.managed, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagedRecordCountFallsBackToAllRecords(t *testing.T) {
+	const owned = `"heritage=external-dns,external-dns/owner=default"`
+
+	zone := &Zone{
+		ID:     1,
+		Domain: "example.com",
+		Records: []*Record{
+			{ID: 1, Name: "www", Type: RecordTypeA, Value: "1.2.3.4"},
+			{ID: 2, Name: "api", Type: RecordTypeA, Value: "1.2.3.5"},
+			// Written with a prefix the provider has not been told about.
+			{ID: 3, Name: "other-a-www", Type: RecordTypeTXT, Value: owned},
+		},
+	}
+
+	if got := newTestProvider(Options{}).managedRecordCount(zone); got != 2 {
+		t.Errorf("managedRecordCount() = %d, want 2", got)
+	}
+
+	if got := newTestProvider(Options{TXTPrefix: "other-"}).managedRecordCount(zone); got != 1 {
+		t.Errorf("managedRecordCount() with prefix = %d, want 1", got)
+	}
+}
@@ -0,0 +1,79 @@
+package bunny
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+type loggerKey struct{}
+
+// withLogger returns a context carrying the given logger.
+func withLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// logger returns the logger carried by the context, or the default logger.
+func logger(ctx context.Context) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+
+	return slog.Default()
+}
+
+// logBuffer is a slog.Handler holding on to log records until they are
+// flushed. Changes applied concurrently log through a buffer each, and the
+// buffers are flushed in the order of the changes, so the log of a batch
+// reads the same regardless of how the changes were scheduled.
+type logBuffer struct {
+	next    slog.Handler
+	entries *logEntries
+}
+
+type logEntries struct {
+	mu      sync.Mutex
+	records []bufferedRecord
+}
+
+type bufferedRecord struct {
+	handler slog.Handler
+	record  slog.Record
+}
+
+func newLogBuffer(next slog.Handler) *logBuffer {
+	return &logBuffer{next: next, entries: &logEntries{}}
+}
+
+func (b *logBuffer) Enabled(ctx context.Context, level slog.Level) bool {
+	return b.next.Enabled(ctx, level)
+}
+
+func (b *logBuffer) Handle(_ context.Context, record slog.Record) error {
+	b.entries.mu.Lock()
+	defer b.entries.mu.Unlock()
+
+	b.entries.records = append(b.entries.records, bufferedRecord{handler: b.next, record: record.Clone()})
+
+	return nil
+}
+
+func (b *logBuffer) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &logBuffer{next: b.next.WithAttrs(attrs), entries: b.entries}
+}
+
+func (b *logBuffer) WithGroup(name string) slog.Handler {
+	return &logBuffer{next: b.next.WithGroup(name), entries: b.entries}
+}
+
+// flush writes the buffered records to the underlying handler.
+func (b *logBuffer) flush(ctx context.Context) {
+	b.entries.mu.Lock()
+	defer b.entries.mu.Unlock()
+
+	for _, entry := range b.entries.records {
+		_ = entry.handler.Handle(ctx, entry.record)
+	}
+
+	b.entries.records = nil
+}
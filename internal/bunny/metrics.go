@@ -0,0 +1,122 @@
+package bunny
+
+import (
+	"errors"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const metricsNamespace = "external_dns_bunny"
+
+var (
+	recordMonitorUp = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "record_monitor_up",
+		Help:      "Whether a monitored record is online (1) or has been taken out of rotation by Bunny.net (0).",
+	}, []string{"zone", "name", "type", "monitor_type"})
+
+	recordsAge = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "records_age_seconds",
+		Help:      "Age of the zones the records last returned to ExternalDNS were read from.",
+	})
+
+	staleRecordsServed = promauto.NewCounter(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "stale_records_served_total",
+		Help:      "Number of times the last known records were returned because the zones could not be fetched.",
+	})
+
+	records = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: metricsNamespace,
+		Name:      "records",
+		Help:      "Number of records in the zones last read by ExternalDNS, by zone and Bunny.net record type.",
+	}, []string{"zone", "type"})
+
+	apiRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_requests_total",
+		Help:      "Number of requests to the Bunny.net API, by API method and response status.",
+	}, []string{"method", "status"})
+
+	apiRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "api_request_duration_seconds",
+		Help:      "Latency of requests to the Bunny.net API, by API method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	changesApplied = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "changes_total",
+		Help:      "Number of changes attempted, by change kind and outcome (applied, failed or refused).",
+	}, []string{"kind", "outcome"})
+
+	zoneCacheRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "zone_cache_requests_total",
+		Help:      "Number of reads of the zone cache, by result (hit or miss).",
+	}, []string{"result"})
+
+	zoneLoadDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "zone_load_duration_seconds",
+		Help:      "Time taken to load all zones from the Bunny.net API, by result (success or error).",
+		Buckets:   []float64{0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"result"})
+)
+
+// updateMonitorMetrics replaces the monitor gauges with the status of the
+// monitored records in the given zones. Records whose status is not known
+// yet are not reported.
+func updateMonitorMetrics(zones []*Zone) {
+	recordMonitorUp.Reset()
+
+	for _, zone := range zones {
+		for _, record := range zone.Records {
+			if record.MonitorType == MonitorTypeNone || record.MonitorStatus == MonitorStatusUnknown {
+				continue
+			}
+
+			var up float64
+			if record.MonitorStatus == MonitorStatusOnline {
+				up = 1
+			}
+
+			recordMonitorUp.WithLabelValues(
+				zone.Domain,
+				record.Name,
+				record.Type.String(),
+				record.MonitorType.String(),
+			).Set(up)
+		}
+	}
+}
+
+// updateRecordMetrics replaces the record gauges with the number of records
+// per zone and type in the given zones.
+func updateRecordMetrics(zones []*Zone) {
+	records.Reset()
+
+	for _, zone := range zones {
+		for _, record := range zone.Records {
+			records.WithLabelValues(zone.Domain, record.Type.String()).Inc()
+		}
+	}
+}
+
+// recordChangeMetrics counts the outcomes of the changes in a batch.
+func recordChangeMetrics(outcomes []ChangeOutcome) {
+	for _, outcome := range outcomes {
+		result := "applied"
+		switch {
+		case errors.Is(outcome.Err, errUnmanagedRecord):
+			result = "refused"
+		case outcome.Err != nil:
+			result = "failed"
+		}
+
+		changesApplied.WithLabelValues(outcome.Kind, result).Inc()
+	}
+}
@@ -0,0 +1,68 @@
+package bunny
+
+import (
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// changeStep is a single change in the execution order of a batch.
+type changeStep struct {
+	Kind     string
+	Endpoint *endpoint.Endpoint
+}
+
+// orderChanges returns the order in which the changes of a batch are applied.
+//
+// Creates are applied first and deletes last, so that a record moving
+// between names is never missing. A delete that frees a name needed by a
+// create in the same batch is moved in front of the creates though, as
+// Bunny.net rejects the create while the old record exists. This is the
+// case when both records share the same name and type, or when either of
+// them is a CNAME, which cannot coexist with any other record.
+func orderChanges(creates []*endpoint.Endpoint, updates []*endpoint.Endpoint, deletes []*endpoint.Endpoint) []changeStep {
+	createsByName := make(map[string][]*endpoint.Endpoint)
+	for _, create := range creates {
+		createsByName[create.DNSName] = append(createsByName[create.DNSName], create)
+	}
+
+	var early, late []*endpoint.Endpoint
+	for _, deletion := range deletes {
+		if conflictsWithAny(deletion, createsByName[deletion.DNSName]) {
+			early = append(early, deletion)
+		} else {
+			late = append(late, deletion)
+		}
+	}
+
+	steps := make([]changeStep, 0, len(creates)+len(updates)+len(deletes))
+	for _, ep := range early {
+		steps = append(steps, changeStep{Kind: ChangeKindDelete, Endpoint: ep})
+	}
+
+	for _, ep := range creates {
+		steps = append(steps, changeStep{Kind: ChangeKindCreate, Endpoint: ep})
+	}
+
+	for _, ep := range late {
+		steps = append(steps, changeStep{Kind: ChangeKindDelete, Endpoint: ep})
+	}
+
+	for _, ep := range updates {
+		steps = append(steps, changeStep{Kind: ChangeKindUpdate, Endpoint: ep})
+	}
+
+	return steps
+}
+
+// conflictsWithAny reports whether the existing record of the given endpoint
+// prevents any of the given endpoints with the same name from being created.
+func conflictsWithAny(existing *endpoint.Endpoint, creates []*endpoint.Endpoint) bool {
+	for _, create := range creates {
+		if existing.RecordType == create.RecordType ||
+			existing.RecordType == endpoint.RecordTypeCNAME ||
+			create.RecordType == endpoint.RecordTypeCNAME {
+			return true
+		}
+	}
+
+	return false
+}
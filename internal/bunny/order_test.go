@@ -0,0 +1,76 @@
+package bunny
+
+import (
+	"slices"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestOrderChanges(t *testing.T) {
+	ep := func(name, recordType string) *endpoint.Endpoint {
+		return endpoint.NewEndpoint(name, recordType, "target")
+	}
+
+	tests := []struct {
+		name    string
+		creates []*endpoint.Endpoint
+		updates []*endpoint.Endpoint
+		deletes []*endpoint.Endpoint
+		want    []string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:    "creates before deletes before updates",
+			creates: []*endpoint.Endpoint{ep("new", endpoint.RecordTypeA)},
+			updates: []*endpoint.Endpoint{ep("changed", endpoint.RecordTypeA)},
+			deletes: []*endpoint.Endpoint{ep("old", endpoint.RecordTypeA)},
+			want:    []string{"create new A", "delete old A", "update changed A"},
+		},
+		{
+			name:    "delete of the same name and type first",
+			creates: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeA)},
+			deletes: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeA)},
+			want:    []string{"delete www A", "create www A"},
+		},
+		{
+			name:    "different types of the same name keep their order",
+			creates: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeA)},
+			deletes: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeTXT)},
+			want:    []string{"create www A", "delete www TXT"},
+		},
+		{
+			name:    "deleted CNAME first",
+			creates: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeA)},
+			deletes: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeCNAME)},
+			want:    []string{"delete www CNAME", "create www A"},
+		},
+		{
+			name:    "created CNAME after delete",
+			creates: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeCNAME)},
+			deletes: []*endpoint.Endpoint{ep("www", endpoint.RecordTypeA), ep("other", endpoint.RecordTypeA)},
+			want:    []string{"delete www A", "create www CNAME", "delete other A"},
+		},
+		{
+			name:    "order within a kind is kept",
+			creates: []*endpoint.Endpoint{ep("b", endpoint.RecordTypeA), ep("a", endpoint.RecordTypeA)},
+			deletes: []*endpoint.Endpoint{ep("d", endpoint.RecordTypeA), ep("c", endpoint.RecordTypeA)},
+			want:    []string{"create b A", "create a A", "delete d A", "delete c A"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, step := range orderChanges(tt.creates, tt.updates, tt.deletes) {
+				got = append(got, step.Kind+" "+step.Endpoint.DNSName+" "+step.Endpoint.RecordType)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("orderChanges() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
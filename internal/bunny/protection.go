@@ -0,0 +1,115 @@
+package bunny
+
+import (
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// isManagedRecord reports whether the record was written by the provider,
+// which tags every record it writes with the external-dns heritage in the
+// record comment.
+func isManagedRecord(r *Record) bool {
+	_, _, ok := splitComment(r.Comment)
+	return ok
+}
+
+// managedRecords returns the IDs of the records in the zone that are managed
+// by external-dns, either tagged with the heritage in their comment or owned
+// according to a TXT registry record. The value reports whether the record is
+// a registry record itself.
+func (p *Provider) managedRecords(zone *Zone) map[int64]bool {
+	managed := make(map[int64]bool)
+	registry := make(registryIndex)
+	var records []*Record
+	var endpoints []*endpoint.Endpoint
+	for _, record := range zone.Records {
+		ep, ok := recordToEndpoint(zone.Domain, record)
+		if !ok {
+			continue
+		}
+
+		if registry.add(ep) {
+			managed[record.ID] = true
+			continue
+		}
+
+		records = append(records, record)
+		endpoints = append(endpoints, ep)
+	}
+
+	for i, ep := range endpoints {
+		if isManagedRecord(records[i]) || registry.owns(p.registry, ep) {
+			managed[records[i].ID] = false
+		}
+	}
+
+	return managed
+}
+
+// isManaged reports whether the record in the zone is managed by
+// external-dns, either tagged in its comment or owned by a TXT registry
+// record in the zone.
+func (p *Provider) isManaged(zone *Zone, record *Record) bool {
+	if isManagedRecord(record) {
+		return true
+	}
+
+	ep, ok := recordToEndpoint(zone.Domain, record)
+
+	return ok && p.registryIndex(zone).owns(p.registry, ep)
+}
+
+// tagsRecords reports whether records written by the provider must carry the
+// external-dns heritage in their comment.
+func (p *Provider) tagsRecords() bool {
+	return p.Options.CommentRegistry || p.Options.ProtectUnmanaged
+}
+
+// rejectUnmanaged splits the given endpoints into those that may be changed
+// and those that refer to records not managed by the provider. Unmanaged
+// records are only rejected when protection is enabled, and records
+// explicitly allowed through the options are never rejected.
+//
+// Whether a record is managed is decided on the record the endpoint resolved
+// to. Records owned by a TXT registry record in the zone count as managed,
+// which is how records written before protection was enabled are adopted.
+// They are tagged by their next update.
+func (p *Provider) rejectUnmanaged(ctx context.Context, identifiers map[endpoint.EndpointKey]identifierTuple, endpoints []*endpoint.Endpoint, action string) ([]*endpoint.Endpoint, []*endpoint.Endpoint) {
+	if !p.Options.ProtectUnmanaged {
+		return endpoints, nil
+	}
+
+	var allowed, rejected []*endpoint.Endpoint
+	for _, ep := range endpoints {
+		tuple, ok := identifiers[identifierKey(ep)]
+		if !ok || tuple.Managed || p.isUnmanagedAllowed(ep.DNSName) {
+			allowed = append(allowed, ep)
+			continue
+		}
+
+		slog.WarnContext(ctx, "Refusing to "+action+" record not managed by external-dns.",
+			slog.Int64("zone_id", tuple.ZoneID),
+			slog.Group("record",
+				slog.Int64("id", tuple.RecordID),
+				slog.String("name", ep.DNSName),
+				slog.String("type", ep.RecordType),
+				slog.String("comment", tuple.Record.Comment),
+			))
+
+		rejected = append(rejected, ep)
+	}
+
+	return allowed, rejected
+}
+
+// isUnmanagedAllowed reports whether changes to an unmanaged record with the
+// given DNS name have been explicitly allowed.
+func (p *Provider) isUnmanagedAllowed(dnsName string) bool {
+	return slices.ContainsFunc(p.Options.AllowUnmanaged, func(allowed string) bool {
+		return strings.EqualFold(strings.TrimSuffix(allowed, "."), dnsName)
+	})
+}
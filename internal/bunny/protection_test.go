@@ -0,0 +1,82 @@
+package bunny
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/puzpuzpuz/xsync/v3"
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// newTestProvider returns a provider serving the given zones from its cache,
+// backed by a fake client holding the same zones.
+func newTestProvider(options Options, zones ...*Zone) *Provider {
+	p := &Provider{
+		Options:     options,
+		client:      newFakeClient(zones...),
+		registry:    newRegistryNaming(options),
+		zoneMap:     xsync.NewMapOf[string, int64](),
+		cache:       newZoneCache(time.Hour),
+		lastApplied: xsync.NewMapOf[int64, appliedRecord](),
+	}
+
+	for _, zone := range zones {
+		p.cacheZone(zone)
+	}
+
+	p.cache.store(0, zones)
+
+	return p
+}
+
+func TestRejectUnmanaged(t *testing.T) {
+	const owned = `"heritage=external-dns,external-dns/owner=default"`
+	const tag = "heritage=external-dns,external-dns/owner=default"
+
+	zone := &Zone{
+		ID:     1,
+		Domain: "example.com",
+		Records: []*Record{
+			// A record created by hand next to the one written by the
+			// provider.
+			{ID: 1, Name: "dup", Type: RecordTypeA, Value: "1.1.1.1"},
+			{ID: 2, Name: "dup", Type: RecordTypeA, Value: "2.2.2.2", Comment: tag},
+			{ID: 3, Name: "manual", Type: RecordTypeA, Value: "3.3.3.3"},
+			{ID: 4, Name: "legacy", Type: RecordTypeA, Value: "4.4.4.4"},
+			{ID: 5, Name: "test-a-legacy", Type: RecordTypeTXT, Value: owned},
+		},
+	}
+
+	p := newTestProvider(Options{ProtectUnmanaged: true, TXTPrefix: "test-"}, zone)
+
+	// external-dns labels every endpoint read through the TXT registry,
+	// which must not make unmanaged records count as managed.
+	labelled := func(name string) *endpoint.Endpoint {
+		ep := endpoint.NewEndpoint(name, endpoint.RecordTypeA, "9.9.9.9")
+		ep.Labels[endpoint.OwnerLabelKey] = "default"
+
+		return ep
+	}
+
+	deletes := []*endpoint.Endpoint{labelled("dup.example.com"), labelled("manual.example.com"), labelled("legacy.example.com")}
+
+	identifiers, err := p.fetchIdentifiers(context.Background(), deletes)
+	if err != nil {
+		t.Fatalf("fetchIdentifiers() error = %v", err)
+	}
+
+	if got := identifiers[identifierKey(deletes[0])].RecordID; got != 2 {
+		t.Errorf("fetchIdentifiers() resolved dup.example.com to record %d, want the tagged record 2", got)
+	}
+
+	allowed, rejected := p.rejectUnmanaged(context.Background(), identifiers, deletes, "delete")
+
+	if len(allowed) != 2 || allowed[0] != deletes[0] || allowed[1] != deletes[2] {
+		t.Errorf("rejectUnmanaged() allowed = %v, want dup and legacy", allowed)
+	}
+
+	if len(rejected) != 1 || rejected[0] != deletes[1] {
+		t.Errorf("rejectUnmanaged() rejected = %v, want manual", rejected)
+	}
+}
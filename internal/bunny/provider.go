@@ -2,15 +2,22 @@ package bunny
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log/slog"
+	"maps"
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
 	"github.com/puzpuzpuz/xsync/v3"
 	"github.com/samber/lo"
 	"github.com/samber/oops"
+	"golang.org/x/sync/errgroup"
 	"sigs.k8s.io/external-dns/endpoint"
 	"sigs.k8s.io/external-dns/plan"
 	"sigs.k8s.io/external-dns/provider"
@@ -23,30 +30,105 @@ var (
 const (
 	providerValueZoneId   = "BunnyZoneID"
 	providerValueRecordId = "BunnyRecordID"
+
+	// zonesPerPage is the number of zones requested per page.
+	zonesPerPage = 1000
+
+	// maxConcurrentZonePages is the number of zone pages fetched at the same
+	// time.
+	maxConcurrentZonePages = 4
 )
 
 type Options struct {
-	APIKey               string   `env:"API_KEY, required"`
-	DryRun               bool     `env:"DRY_RUN, default=false"`
-	ExcludeDomains       []string `env:"EXCLUDE_DOMAINS"`
-	ExcludeDomainsRegexp string   `env:"EXCLUDE_DOMAINS_REGEXP"`
-	IncludeDomains       []string `env:"INCLUDE_DOMAINS"`
-	IncludeDomainsRegexp string   `env:"INCLUDE_DOMAINS_REGEXP"`
+	APIKey                 string   `env:"API_KEY, required"`
+	DryRun                 bool     `env:"DRY_RUN, default=false"`
+	ExcludeDomains         []string `env:"EXCLUDE_DOMAINS"`
+	ExcludeDomainsRegexp   string   `env:"EXCLUDE_DOMAINS_REGEXP"`
+	IncludeDomains         []string `env:"INCLUDE_DOMAINS"`
+	IncludeDomainsRegexp   string   `env:"INCLUDE_DOMAINS_REGEXP"`
+	DefaultComment         string   `env:"DEFAULT_COMMENT"`
+	CommentRegistry        bool     `env:"COMMENT_REGISTRY, default=false"`
+	TXTPrefix              string   `env:"TXT_PREFIX"`
+	TXTSuffix              string   `env:"TXT_SUFFIX"`
+	TXTWildcardReplacement string   `env:"TXT_WILDCARD_REPLACEMENT"`
+	ProtectUnmanaged       bool     `env:"PROTECT_UNMANAGED, default=false"`
+	AllowUnmanaged         []string `env:"ALLOW_UNMANAGED"`
+	MaxDeletes             int      `env:"MAX_DELETES, default=0"`
+	MaxDeletesPercent      int      `env:"MAX_DELETES_PERCENT, default=0"`
+	MaxUpdates             int      `env:"MAX_UPDATES, default=0"`
+	MaxUpdatesPercent      int      `env:"MAX_UPDATES_PERCENT, default=0"`
+	GuardrailOverrideFile  string   `env:"GUARDRAIL_OVERRIDE_FILE"`
+	SnapshotDir            string   `env:"SNAPSHOT_DIR"`
+	SnapshotRetention      int      `env:"SNAPSHOT_RETENTION, default=20"`
+	ApprovalFile           string   `env:"APPROVAL_FILE"`
+	ApprovalZones          []string `env:"APPROVAL_ZONES"`
+	ApprovalRecordTypes    []string `env:"APPROVAL_RECORD_TYPES"`
+	ApprovalChangeKinds    []string `env:"APPROVAL_CHANGE_KINDS"`
+	ContinueOnError        bool     `env:"CONTINUE_ON_ERROR, default=false"`
+	Transactional          bool     `env:"TRANSACTIONAL, default=false"`
+	Concurrency            int      `env:"CONCURRENCY, default=1"`
+
+	CacheTTL     time.Duration `env:"CACHE_TTL, default=30s"`
+	MaxStaleness time.Duration `env:"MAX_STALENESS, default=0"`
+	StateFile    string        `env:"STATE_FILE"`
 }
 
 type Provider struct {
 	Options Options
 	client  Client
-	filter  endpoint.DomainFilterInterface
-	zoneMap *xsync.MapOf[string, int64]
+	filter  endpoint.DomainFilter
+	// registry names the records of the external-dns TXT registry.
+	registry registryNaming
+	zoneMap  *xsync.MapOf[string, int64]
+	cache    *zoneCache
+	stale    atomic.Bool
+
+	persistMu sync.Mutex
+
+	// applyMu serializes the changes applied by external-dns, approvals
+	// and drift reverts.
+	applyMu sync.Mutex
+
+	commentTemplate *template.Template
+	approvals       *approvalQueue
+	lastApplied     *xsync.MapOf[int64, appliedRecord]
 }
 
-func NewProvider(client Client, options Options) *Provider {
+func NewProvider(client Client, options Options) (*Provider, error) {
+	commentTemplate, err := parseCommentTemplate(options.DefaultComment)
+	if err != nil {
+		return nil, oops.In("Provider").Wrapf(err, "failed to parse the default comment template")
+	}
+
 	provider := &Provider{
-		Options: options,
-		client:  client,
-		filter:  getDomainFilter(options),
-		zoneMap: xsync.NewMapOf[string, int64](),
+		Options:  options,
+		client:   client,
+		filter:   getDomainFilter(options),
+		registry: newRegistryNaming(options),
+		zoneMap:  xsync.NewMapOf[string, int64](),
+		cache:    newZoneCache(options.CacheTTL),
+
+		commentTemplate: commentTemplate,
+		lastApplied:     xsync.NewMapOf[int64, appliedRecord](),
+	}
+
+	// Approvals are opt-in, and a queue that cannot be loaded must not
+	// silently turn into changes being applied without approval.
+	if options.ApprovalFile != "" {
+		approvals, err := newApprovalQueue(options.ApprovalFile)
+		if err != nil {
+			return nil, oops.In("Provider").With("path", options.ApprovalFile).Wrapf(err, "failed to load approval queue")
+		}
+
+		provider.approvals = approvals
+	}
+
+	// Zones persisted by a previous run can be served right away, while
+	// the live zones are fetched in the background.
+	if options.StateFile != "" && provider.loadStateFile(context.Background()) {
+		go provider.refreshSeededZones(context.Background())
+
+		return provider, nil
 	}
 
 	// On startup, fetch zones so that all available zones are cached. This
@@ -55,13 +137,13 @@ func NewProvider(client Client, options Options) *Provider {
 	// to accurately exctract recordName from the full dnsName. Without it,
 	// we could not accurately handle all the expected TLDs without maintaing
 	// an internal list.
-	_, err := provider.fetchZones(context.Background())
+	_, err = provider.fetchZones(context.Background())
 	if err != nil {
 		slog.Error("Failed to fetch zones on startup.",
 			slog.Any("error", err))
 	}
 
-	return provider
+	return provider, nil
 }
 
 func (p *Provider) allZones() []string {
@@ -83,7 +165,7 @@ func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	errs := oops.In("Provider").
 		Span("Records")
 
-	zones, err := p.fetchZones(ctx)
+	zones, err := p.recordZones(ctx)
 	if err != nil {
 		slog.Error("Failed to fetch zones",
 			slog.Any("error", err))
@@ -94,30 +176,70 @@ func (p *Provider) Records(ctx context.Context) ([]*endpoint.Endpoint, error) {
 	var endpoints []*endpoint.Endpoint
 	for _, zone := range zones {
 		for _, record := range zone.Records {
-			// First check if the record type is supported, and if not
-			// skip the record altogether.
-			if !provider.SupportedRecordType(record.Type.String()) {
+			// Skip records whose type cannot be represented as an
+			// endpoint altogether.
+			ep, ok := recordToEndpoint(zone.Domain, record)
+			if !ok {
+				continue
+			}
+
+			// Zones are only listed if they may hold matching records,
+			// but the records in them can still fall outside the filter.
+			if !p.filter.Match(ep.DNSName) {
 				continue
 			}
 
-			endpoints = append(endpoints, recordToEndpoint(zone.Domain, record))
+			if p.Options.CommentRegistry {
+				// Ownership is tracked in the record comments, so any
+				// registry TXT records left behind are hidden from
+				// external-dns.
+				if isRegistryRecord(ep) {
+					continue
+				}
+
+				if _, labels, ok := splitComment(record.Comment); ok {
+					maps.Copy(ep.Labels, labels)
+				}
+			}
+
+			endpoints = append(endpoints, ep)
 		}
 	}
 
+	updateMonitorMetrics(zones)
+	updateRecordMetrics(zones)
+
 	return endpoints, nil
 }
 
 func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) error {
+	if changes == nil || !changes.HasChanges() {
+		slog.Debug("Skipping request to apply changes because no changes are present")
+
+		return nil
+	}
+
 	errs := oops.In("Provider").
 		With("creates", len(changes.Create)).
 		With("deletes", len(changes.Delete)).
 		With("updates", len(changes.UpdateNew)).
 		Span("ApplyChanges")
 
-	if changes == nil || !changes.HasChanges() {
-		slog.Debug("Skipping request to apply changes because no changes are present")
+	// The changes were planned from the records last returned by Records. If
+	// those were stale, the plan may undo changes made since.
+	if p.stale.Load() {
+		return errs.Errorf("refusing to apply changes planned from stale records")
+	}
 
-		return nil
+	// When ownership is tracked in record comments, the TXT records the
+	// external-dns registry asks for are redundant and never written.
+	if p.Options.CommentRegistry {
+		changes = &plan.Changes{
+			Create:    withoutRegistryRecords(changes.Create),
+			UpdateOld: withoutRegistryRecords(changes.UpdateOld),
+			UpdateNew: withoutRegistryRecords(changes.UpdateNew),
+			Delete:    withoutRegistryRecords(changes.Delete),
+		}
 	}
 
 	// If we are in dry-run mode, we can skip the creation of endpoints and
@@ -126,54 +248,109 @@ func (p *Provider) ApplyChanges(ctx context.Context, changes *plan.Changes) erro
 		return p.applyChangesDryRun(ctx, changes)
 	}
 
-	err := p.createEndpoints(ctx, changes.Create)
+	// Changes that require approval are parked in the approval queue and
+	// applied once an operator approves them.
+	changes, err := p.parkChanges(ctx, changes)
 	if err != nil {
-		slog.Error("Failed to create endpoints",
-			slog.Any("error", err))
-
-		return errs.Wrapf(err, "failed to apply creates")
+		return errs.Wrapf(err, "failed to park changes for approval")
 	}
 
-	// If we have no deletions or updates, we can return early to avoid making a (potentially)
-	// expensive call to the Bunny.net API.
-	if len(changes.Delete) == 0 && len(changes.UpdateOld) == 0 {
+	return p.applyChanges(ctx, changes)
+}
+
+// applyChanges applies the given changes through the client. It is shared by
+// ApplyChanges and the approval of parked changes.
+func (p *Provider) applyChanges(ctx context.Context, changes *plan.Changes) error {
+	errs := oops.In("Provider").
+		With("creates", len(changes.Create)).
+		With("deletes", len(changes.Delete)).
+		With("updates", len(changes.UpdateNew)).
+		Span("applyChanges")
+
+	if !changes.HasChanges() {
 		return nil
 	}
 
-	var dnsNames []string
-	for _, ep := range changes.Delete {
-		dnsNames = append(dnsNames, ep.DNSName)
-	}
+	p.applyMu.Lock()
+	defer p.applyMu.Unlock()
 
-	for _, ep := range changes.UpdateOld {
-		dnsNames = append(dnsNames, ep.DNSName)
+	// Guardrails are checked before anything is applied, so a refused
+	// batch leaves the zones untouched.
+	err := p.checkGuardrails(ctx, changes)
+	if err != nil {
+		return errs.Wrapf(err, "refused to apply changes")
 	}
 
-	tuples, err := p.fetchIdentifiers(ctx, dnsNames)
+	// The affected zones are snapshotted before they are changed, so the
+	// batch can be reverted with the restore command if it goes wrong.
+	err = p.snapshotZones(ctx, changes)
 	if err != nil {
-		slog.Error("Failed to fetch identifiers",
-			slog.Any("error", err))
+		return errs.Wrapf(err, "failed to snapshot zones")
+	}
 
-		return errs.Wrapf(err, "failed to fetch identifiers")
+	// Identifiers are only needed for deletions and updates, so we can avoid making a
+	// (potentially) expensive call to the Bunny.net API if there are none.
+	tuples := make(map[endpoint.EndpointKey]identifierTuple)
+	if len(changes.Delete) > 0 || len(changes.UpdateOld) > 0 {
+		var lookups []*endpoint.Endpoint
+		lookups = append(lookups, changes.Delete...)
+		lookups = append(lookups, changes.UpdateOld...)
+
+		tuples, err = p.fetchIdentifiers(ctx, lookups)
+		if err != nil {
+			slog.Error("Failed to fetch identifiers",
+				slog.Any("error", err))
+
+			return errs.Wrapf(err, "failed to fetch identifiers")
+		}
 	}
 
-	err = p.deleteEndpoints(ctx, tuples, changes.Delete)
-	if err != nil {
-		slog.Error("Failed to delete endpoints",
-			slog.Any("error", err))
+	// Changes to unmanaged records are refused and reported along with the
+	// outcome of the remaining changes.
+	deletes, rejectedDeletes := p.rejectUnmanaged(ctx, tuples, changes.Delete, "delete")
+	updates, rejectedUpdates := p.rejectUnmanaged(ctx, tuples, changes.UpdateNew, "update")
 
-		return errs.Wrapf(err, "failed to apply deletes")
+	var outcomes []ChangeOutcome
+	for _, ep := range rejectedDeletes {
+		outcomes = append(outcomes, ChangeOutcome{Kind: ChangeKindDelete, Endpoint: ep, Err: errUnmanagedRecord})
 	}
 
-	err = p.updateEndpoints(ctx, tuples, changes.UpdateNew)
+	for _, ep := range rejectedUpdates {
+		outcomes = append(outcomes, ChangeOutcome{Kind: ChangeKindUpdate, Endpoint: ep, Err: errUnmanagedRecord})
+	}
+
+	var applied []*appliedChange
+
+	err = p.applySteps(ctx, orderChanges(changes.Create, updates, deletes), tuples, &outcomes, &applied)
+	recordChangeMetrics(outcomes)
 	if err != nil {
-		slog.Error("Failed to update endpoints",
+		slog.Error("Failed to apply changes",
 			slog.Any("error", err))
 
-		return errs.Wrapf(err, "failed to apply updates")
+		p.invalidateZones()
+
+		// In transactional mode, the changes applied so far are reverted
+		// so the zones are not left in a mixed state.
+		if p.Options.Transactional && len(applied) > 0 {
+			rollbackErr := p.rollback(ctx, applied)
+			p.untrackApplied(applied)
+
+			if rollbackErr != nil {
+				return errs.Wrapf(errors.Join(err, rollbackErr), "failed to apply changes and roll them back")
+			}
+
+			return errs.Wrapf(err, "failed to apply changes, applied changes have been rolled back")
+		}
+
+		p.trackApplied(applied)
+
+		return errs.Wrapf(err, "failed to apply changes")
 	}
 
-	return nil
+	p.trackApplied(applied)
+	p.updateCachedZones(applied, outcomes)
+
+	return outcomeError(ctx, outcomes)
 }
 
 func (p *Provider) applyChangesDryRun(ctx context.Context, changes *plan.Changes) error {
@@ -204,16 +381,11 @@ func (p *Provider) applyChangesDryRun(ctx context.Context, changes *plan.Changes
 		return nil
 	}
 
-	var dnsNames []string
-	for _, ep := range changes.Delete {
-		dnsNames = append(dnsNames, ep.DNSName)
-	}
+	var lookups []*endpoint.Endpoint
+	lookups = append(lookups, changes.Delete...)
+	lookups = append(lookups, changes.UpdateOld...)
 
-	for _, ep := range changes.UpdateOld {
-		dnsNames = append(dnsNames, ep.DNSName)
-	}
-
-	tuples, err := p.fetchIdentifiers(ctx, dnsNames)
+	tuples, err := p.fetchIdentifiers(ctx, lookups)
 	if err != nil {
 		slog.Error("Failed to fetch identifiers",
 			slog.Any("error", err))
@@ -221,8 +393,12 @@ func (p *Provider) applyChangesDryRun(ctx context.Context, changes *plan.Changes
 		return errs.Wrapf(err, "failed to fetch identifiers")
 	}
 
-	for _, ep := range changes.Delete {
-		tuple, ok := tuples[ep.DNSName]
+	// Unmanaged records are logged by rejectUnmanaged and skipped below.
+	deletes, _ := p.rejectUnmanaged(ctx, tuples, changes.Delete, "delete")
+	updates, _ := p.rejectUnmanaged(ctx, tuples, changes.UpdateOld, "update")
+
+	for _, ep := range deletes {
+		tuple, ok := tuples[identifierKey(ep)]
 		if !ok {
 			slog.InfoContext(ctx, "DRY RUN: Delete record (would skip, not found in Bunny API)",
 				slog.Group("record",
@@ -246,8 +422,8 @@ func (p *Provider) applyChangesDryRun(ctx context.Context, changes *plan.Changes
 			))
 	}
 
-	for _, ep := range changes.UpdateOld {
-		tuple, ok := tuples[ep.DNSName]
+	for _, ep := range updates {
+		tuple, ok := tuples[identifierKey(ep)]
 		if !ok {
 			slog.InfoContext(ctx, "DRY RUN: Update record (would skip, not found in Bunny API)",
 				slog.Group("current",
@@ -307,12 +483,31 @@ func (p *Provider) AdjustEndpoints(incoming []*endpoint.Endpoint) ([]*endpoint.E
 	}
 
 	for _, editing := range incoming {
+		if err := canonicalizeEndpoint(editing); err != nil {
+			return nil, errs.Wrapf(err, "failed to adjust endpoint %q", editing.DNSName)
+		}
+
 		for _, checked := range fetched {
 			if editing.DNSName != checked.DNSName || editing.RecordType != checked.RecordType || editing.SetIdentifier != checked.SetIdentifier {
 				continue
 			}
 
+			// Records keep their comment unless one is set explicitly, so
+			// comments rendered from the default template do not cause an
+			// update on every synchronization.
+			if _, ok := editing.GetProviderSpecificProperty(providerSpecificComment); !ok {
+				if comment, ok := checked.GetProviderSpecificProperty(providerSpecificComment); ok {
+					editing.SetProviderSpecificProperty(providerSpecificComment, comment)
+				}
+			}
+
 			for key, value := range checked.Labels {
+				// The monitor status is informational only and must not
+				// end up in the registry.
+				if key == labelMonitorStatus {
+					continue
+				}
+
 				editing.Labels[key] = value
 			}
 		}
@@ -347,74 +542,75 @@ func (p *Provider) getZoneID(dnsName string) (int64, error) {
 	return zoneID, nil
 }
 
-// createEndpoints creates the given endpoints.
-func (p *Provider) createEndpoints(ctx context.Context, creates []*endpoint.Endpoint) error {
+// createEndpoint creates the record for the given endpoint.
+func (p *Provider) createEndpoint(ctx context.Context, create *endpoint.Endpoint) (*appliedChange, error) {
 	errs := oops.In("Provider").
-		Span("createEndpoints").
-		With("creates", len(creates))
+		Span("createEndpoint").
+		With("dnsName", create.DNSName)
 
-	for _, create := range creates {
-		bunnyZoneID, err := p.getZoneID(create.DNSName)
-		if err != nil {
-			return errs.Wrapf(err, "failed to create record %q", create.DNSName)
-		}
+	bunnyZoneID, err := p.getZoneID(create.DNSName)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to create record %q", create.DNSName)
+	}
 
-		recordName, domainName, ok := extractRecordComponents(p.allZones(), create.DNSName)
-		if !ok {
-			return errs.Errorf("failed to extract components for %q", create.DNSName)
-		}
+	recordName, domainName, ok := extractRecordComponents(p.allZones(), create.DNSName)
+	if !ok {
+		return nil, errs.Errorf("failed to extract components for %q", create.DNSName)
+	}
 
-		opts, err := providerSpecificOptionsFromEndpoint(create)
-		if err != nil {
-			return errs.Wrapf(err, "failed to create record %q", create.DNSName)
-		}
+	opts, err := providerSpecificOptionsFromEndpoint(create)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to create record %q", create.DNSName)
+	}
 
-		record := CreateRecordRequest{
-			Name:        recordName,
-			Type:        RecordTypeFromString(create.RecordType),
-			Value:       create.Targets[0],
-			TTLSeconds:  int(create.RecordTTL),
-			MonitorType: opts.MonitorType,
-			Weight:      opts.Weight,
-			Disabled:    opts.Disabled,
-		}
+	record := CreateRecordRequest{
+		Name:        recordName,
+		Type:        recordTypeFromEndpoint(create, recordName, opts),
+		Value:       recordValueFromEndpoint(create, opts),
+		TTLSeconds:  int(create.RecordTTL),
+		MonitorType: opts.MonitorType,
+		Weight:      opts.Weight,
+		Disabled:    opts.Disabled,
+	}
 
-		slog.Debug("Creating Record.",
-			slog.String("zone", domainName),
-			slog.Int64("zone_id", bunnyZoneID),
-			slog.Group("record",
-				slog.String("name", record.Name),
-				slog.String("type", record.Type.String()),
-				slog.String("value", record.Value),
-				slog.Int("ttl", record.TTLSeconds),
-				slog.String("monitor_type", record.MonitorType.String()),
-				slog.Int("weight", record.Weight),
-				slog.Bool("disabled", record.Disabled),
-			),
-		)
+	if opts.IsRedirect() {
+		record.RedirectStatusCode = opts.RedirectStatusCode
+		record.RedirectPreservePath = opts.RedirectPreservePath
+	}
 
-		created, err := p.client.CreateRecord(ctx, strconv.FormatInt(bunnyZoneID, 10), record)
-		if err != nil {
-			slog.Error("Failed to create record.",
-				slog.Any("error", err),
-				slog.Group("record",
-					slog.String("name", record.Name),
-					slog.String("type", record.Type.String()),
-					slog.String("value", record.Value),
-					slog.Int("ttl", record.TTLSeconds),
-					slog.String("monitor_type", record.MonitorType.String()),
-					slog.Int("weight", record.Weight),
-					slog.Bool("disabled", record.Disabled),
-				))
+	if opts.IsScript() {
+		record.ScriptID = opts.ScriptID
+		record.EnvironmentVariables = opts.ScriptEnv
+	}
 
-			return err
+	record.Comment, err = p.recordComment(create, record.Type, opts)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to render comment for record %q", create.DNSName)
+	}
+
+	logger(ctx).DebugContext(ctx, "Creating Record.",
+		slog.String("zone", domainName),
+		slog.Int64("zone_id", bunnyZoneID),
+		slog.Group("record",
+			slog.String("name", record.Name),
+			slog.String("type", record.Type.String()),
+			slog.String("value", record.Value),
+			slog.Int("ttl", record.TTLSeconds),
+			slog.String("monitor_type", record.MonitorType.String()),
+			slog.Int("weight", record.Weight),
+			slog.Bool("disabled", record.Disabled),
+		),
+	)
+
+	created, err := p.client.CreateRecord(ctx, strconv.FormatInt(bunnyZoneID, 10), record)
+	if err != nil {
+		if change, ok := p.adoptExisting(ctx, bunnyZoneID, record, err); ok {
+			return change, nil
 		}
 
-		slog.InfoContext(ctx, "Record created successfully.",
-			slog.String("zone", domainName),
-			slog.Int64("zone_id", bunnyZoneID),
+		logger(ctx).ErrorContext(ctx, "Failed to create record.",
+			slog.Any("error", err),
 			slog.Group("record",
-				slog.Int64("id", created.ID),
 				slog.String("name", record.Name),
 				slog.String("type", record.Type.String()),
 				slog.String("value", record.Value),
@@ -423,101 +619,151 @@ func (p *Provider) createEndpoints(ctx context.Context, creates []*endpoint.Endp
 				slog.Int("weight", record.Weight),
 				slog.Bool("disabled", record.Disabled),
 			))
+
+		return nil, err
 	}
 
-	return nil
+	logger(ctx).InfoContext(ctx, "Record created successfully.",
+		slog.String("zone", domainName),
+		slog.Int64("zone_id", bunnyZoneID),
+		slog.Group("record",
+			slog.Int64("id", created.ID),
+			slog.String("name", record.Name),
+			slog.String("type", record.Type.String()),
+			slog.String("value", record.Value),
+			slog.Int("ttl", record.TTLSeconds),
+			slog.String("monitor_type", record.MonitorType.String()),
+			slog.Int("weight", record.Weight),
+			slog.Bool("disabled", record.Disabled),
+		))
+
+	return &appliedChange{Kind: ChangeKindCreate, ZoneID: bunnyZoneID, RecordID: created.ID, Record: created}, nil
 }
 
-// updateEndpoints updates the given endpoints.
-func (p *Provider) updateEndpoints(ctx context.Context, identifiers map[string]identifierTuple, updates []*endpoint.Endpoint) error {
-	for _, update := range updates {
-		tuple, ok := identifiers[update.DNSName]
-		if !ok {
-			return fmt.Errorf("failed to get record identifiers for %q", update.DNSName)
-		}
+// updateEndpoint updates the record of the given endpoint.
+func (p *Provider) updateEndpoint(ctx context.Context, identifiers map[endpoint.EndpointKey]identifierTuple, update *endpoint.Endpoint) (*appliedChange, error) {
+	tuple, ok := identifiers[identifierKey(update)]
+	if !ok {
+		return nil, fmt.Errorf("failed to get record identifiers for %q", update.DNSName)
+	}
 
-		opts, err := providerSpecificOptionsFromEndpoint(update)
-		if err != nil {
-			return fmt.Errorf("failed to update record %q", update.DNSName)
-		}
+	opts, err := providerSpecificOptionsFromEndpoint(update)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update record %q: %w", update.DNSName, err)
+	}
 
-		record := UpdateRecordRequest{
-			TTLSeconds:  int(update.RecordTTL),
-			Value:       update.Targets[0],
-			MonitorType: opts.MonitorType,
-			Weight:      opts.Weight,
-			Disabled:    opts.Disabled,
-		}
+	record := UpdateRecordRequest{
+		Type:        recordTypeFromEndpoint(update, tuple.RecordName, opts),
+		TTLSeconds:  int(update.RecordTTL),
+		Value:       recordValueFromEndpoint(update, opts),
+		MonitorType: opts.MonitorType,
+		Weight:      opts.Weight,
+		Disabled:    opts.Disabled,
+	}
 
-		err = p.client.UpdateRecord(ctx, tuple.ZoneID, tuple.RecordID, record)
-		if err != nil {
-			return err
-		}
+	if opts.IsRedirect() {
+		record.RedirectStatusCode = opts.RedirectStatusCode
+		record.RedirectPreservePath = opts.RedirectPreservePath
+	}
 
-		slog.InfoContext(ctx, "Updated record.",
-			slog.Int64("zone_id", tuple.ZoneID),
-			slog.Group("record",
-				slog.Int64("id", tuple.RecordID),
-				slog.String("name", update.DNSName),
-				slog.String("value", record.Value),
-				slog.Int("ttl", record.TTLSeconds),
-				slog.String("monitor_type", record.MonitorType.String()),
-				slog.Int("weight", record.Weight),
-				slog.Bool("disabled", record.Disabled),
-			))
+	if opts.IsScript() {
+		record.ScriptID = opts.ScriptID
+		record.EnvironmentVariables = opts.ScriptEnv
 	}
 
-	return nil
-}
+	// Without an explicit comment, the comment of the record is kept rather
+	// than rendering the default template again.
+	if opts.Comment == "" {
+		opts.Comment = existingComment(tuple.Record)
+	}
 
-func (p *Provider) deleteEndpoints(ctx context.Context, identifiers map[string]identifierTuple, deletions []*endpoint.Endpoint) error {
-	for _, deletion := range deletions {
-		tuple, ok := identifiers[deletion.DNSName]
-		if !ok {
-			return fmt.Errorf("failed to get record identifiers for %q", deletion.DNSName)
-		}
+	record.Comment, err = p.recordComment(update, record.Type, opts)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render comment for record %q: %w", update.DNSName, err)
+	}
 
-		opts, err := providerSpecificOptionsFromEndpoint(deletion)
-		if err != nil {
-			// We can ignore this error as we are deleting the record anyway and we'll always
-			// get a usable opts struct (no nil pointers).
-		}
+	err = p.client.UpdateRecord(ctx, tuple.ZoneID, tuple.RecordID, record)
+	if err != nil {
+		return nil, err
+	}
 
-		err = p.client.DeleteRecord(ctx, tuple.ZoneID, tuple.RecordID)
-		if err != nil {
-			return err
-		}
+	logger(ctx).InfoContext(ctx, "Updated record.",
+		slog.Int64("zone_id", tuple.ZoneID),
+		slog.Group("record",
+			slog.Int64("id", tuple.RecordID),
+			slog.String("name", update.DNSName),
+			slog.String("type", record.Type.String()),
+			slog.String("value", record.Value),
+			slog.Int("ttl", record.TTLSeconds),
+			slog.String("monitor_type", record.MonitorType.String()),
+			slog.Int("weight", record.Weight),
+			slog.Bool("disabled", record.Disabled),
+		))
+
+	return &appliedChange{Kind: ChangeKindUpdate, ZoneID: tuple.ZoneID, RecordID: tuple.RecordID, Previous: tuple.Record, Record: recordFromUpdateRequest(tuple.Record, record)}, nil
+}
 
-		slog.InfoContext(ctx, "Deleted record.",
-			slog.Int64("zone_id", tuple.ZoneID),
-			slog.Group("record",
-				slog.Int64("id", tuple.RecordID),
-				slog.String("name", deletion.DNSName),
-				slog.String("value", deletion.Targets[0]),
-				slog.Int("ttl", int(deletion.RecordTTL)),
-				slog.String("monitor_type", opts.MonitorType.String()),
-				slog.Int("weight", opts.Weight),
-				slog.Bool("disabled", opts.Disabled),
-			))
+// deleteEndpoint deletes the record of the given endpoint.
+func (p *Provider) deleteEndpoint(ctx context.Context, identifiers map[endpoint.EndpointKey]identifierTuple, deletion *endpoint.Endpoint) (*appliedChange, error) {
+	tuple, ok := identifiers[identifierKey(deletion)]
+	if !ok {
+		return nil, fmt.Errorf("failed to get record identifiers for %q", deletion.DNSName)
+	}
 
+	opts, err := providerSpecificOptionsFromEndpoint(deletion)
+	if err != nil {
+		// We can ignore this error as we are deleting the record anyway and we'll always
+		// get a usable opts struct (no nil pointers).
 	}
 
-	return nil
+	err = p.client.DeleteRecord(ctx, tuple.ZoneID, tuple.RecordID)
+	if err != nil {
+		return nil, err
+	}
+
+	logger(ctx).InfoContext(ctx, "Deleted record.",
+		slog.Int64("zone_id", tuple.ZoneID),
+		slog.Group("record",
+			slog.Int64("id", tuple.RecordID),
+			slog.String("name", deletion.DNSName),
+			slog.String("value", deletion.Targets[0]),
+			slog.Int("ttl", int(deletion.RecordTTL)),
+			slog.String("monitor_type", opts.MonitorType.String()),
+			slog.Int("weight", opts.Weight),
+			slog.Bool("disabled", opts.Disabled),
+		))
+
+	return &appliedChange{Kind: ChangeKindDelete, ZoneID: tuple.ZoneID, RecordID: tuple.RecordID, Previous: tuple.Record}, nil
 }
 
 type identifierTuple struct {
-	ZoneID   int64
-	RecordID int64
+	ZoneID     int64
+	RecordID   int64
+	RecordName string
+	Record     *Record
+	// Managed reports whether the record is managed by external-dns,
+	// either tagged in its comment or owned by a TXT registry record.
+	Managed bool
+}
+
+// identifierKey returns the key used to look up the identifiers of an
+// endpoint. Bunny.net has no notion of set identifiers, so records are
+// matched on their name and type only.
+func identifierKey(ep *endpoint.Endpoint) endpoint.EndpointKey {
+	return endpoint.EndpointKey{
+		DNSName:    ep.DNSName,
+		RecordType: ep.RecordType,
+	}
 }
 
-// fetchIdentifiers fetches the zone and record identifiers for the given DNS names by listing
-// all zones and records and returning a map of DNS names to identifiers. This allows us to get
-// all the identifiers in a single call (or paginated calls) and then use them to update or delete
-// records.
-func (p *Provider) fetchIdentifiers(ctx context.Context, dnsNames []string) (map[string]identifierTuple, error) {
-	identifiers := make(map[string]identifierTuple)
+// fetchIdentifiers fetches the zone and record identifiers for the given endpoints by listing
+// all zones and records and returning a map of endpoint keys to identifiers. This allows us to
+// get all the identifiers in a single call (or paginated calls) and then use them to update or
+// delete records.
+func (p *Provider) fetchIdentifiers(ctx context.Context, endpoints []*endpoint.Endpoint) (map[endpoint.EndpointKey]identifierTuple, error) {
+	identifiers := make(map[endpoint.EndpointKey]identifierTuple)
 
-	zones, err := p.fetchZones(ctx)
+	zones, err := p.zones(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -527,10 +773,12 @@ func (p *Provider) fetchIdentifiers(ctx context.Context, dnsNames []string) (map
 		domainNames = append(domainNames, zone.Domain)
 	}
 
-	for _, dnsName := range dnsNames {
-		recordName, domainName, ok := extractRecordComponents(domainNames, dnsName)
+	registries := make(map[int64]registryIndex)
+
+	for _, ep := range endpoints {
+		recordName, domainName, ok := extractRecordComponents(domainNames, ep.DNSName)
 		if !ok {
-			return nil, fmt.Errorf("record %q cannot be handled, no matching zone found", dnsName)
+			return nil, fmt.Errorf("record %q cannot be handled, no matching zone found", ep.DNSName)
 		}
 
 		for _, zone := range zones {
@@ -538,15 +786,40 @@ func (p *Provider) fetchIdentifiers(ctx context.Context, dnsNames []string) (map
 				continue
 			}
 
+			// Several records can share a name and type, for example
+			// one created by hand next to one written by the provider.
+			// The record tagged by the provider is preferred.
+			var match *Record
 			for _, record := range zone.Records {
 				if record.Name != recordName {
 					continue
 				}
 
-				identifiers[dnsName] = identifierTuple{
-					ZoneID:   zone.ID,
-					RecordID: record.ID,
+				if recordType, ok := endpointRecordType(record); !ok || recordType != ep.RecordType {
+					continue
 				}
+
+				if match == nil || (!isManagedRecord(match) && isManagedRecord(record)) {
+					match = record
+				}
+			}
+
+			if match == nil {
+				continue
+			}
+
+			registry, ok := registries[zone.ID]
+			if !ok {
+				registry = p.registryIndex(zone)
+				registries[zone.ID] = registry
+			}
+
+			identifiers[identifierKey(ep)] = identifierTuple{
+				ZoneID:     zone.ID,
+				RecordID:   match.ID,
+				RecordName: recordName,
+				Record:     match,
+				Managed:    isManagedRecord(match) || registry.owns(p.registry, ep),
 			}
 		}
 	}
@@ -554,35 +827,125 @@ func (p *Provider) fetchIdentifiers(ctx context.Context, dnsNames []string) (map
 	return identifiers, nil
 }
 
-func (p *Provider) fetchZones(ctx context.Context) ([]*Zone, error) {
-	var page = 1
-	var zones []*Zone
+// listZones lists the zones of the account matching the domain filter. When
+// the filter includes specific domains, only zones found by searching for
+// them are listed.
+func (p *Provider) listZones(ctx context.Context) ([]*Zone, error) {
+	searches := zoneSearches(p.Options)
+	if len(searches) == 0 {
+		searches = []string{""}
+	}
 
-	for {
-		results, err := p.client.ListZones(ctx, ListZonesRequest{
-			Page:    page,
-			PerPage: 1000,
-		})
+	seen := make(map[int64]bool)
 
+	var zones []*Zone
+	for _, search := range searches {
+		pages, err := p.listZonePages(ctx, search)
 		if err != nil {
 			return nil, err
 		}
 
-		for _, zone := range results.Items {
-			// Cache the zone ID for lookup during creates.
-			p.cacheZone(zone)
+		for _, page := range pages {
+			for _, zone := range page {
+				if seen[zone.ID] || !p.zoneMatchesFilter(zone.Domain) {
+					continue
+				}
 
-			zones = append(zones, zone)
+				seen[zone.ID] = true
+
+				// Cache the zone ID for lookup during creates.
+				p.cacheZone(zone)
+
+				zones = append(zones, zone)
+			}
 		}
+	}
+
+	return zones, nil
+}
 
-		if !results.HasMoreItems {
-			break
+// listZonePages lists all pages of zones matching the given search. The first
+// page tells how many zones there are, and the remaining pages are then
+// fetched concurrently.
+func (p *Provider) listZonePages(ctx context.Context, search string) ([][]*Zone, error) {
+	first, err := p.client.ListZones(ctx, ListZonesRequest{
+		Page:    1,
+		PerPage: zonesPerPage,
+		Domain:  search,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pages := [][]*Zone{first.Items}
+
+	if first.HasMoreItems {
+		rest, err := p.listRemainingZonePages(ctx, search, first)
+		if err != nil {
+			return nil, err
 		}
 
-		page++
+		pages = append(pages, rest...)
 	}
 
-	return zones, nil
+	return pages, nil
+}
+
+// listRemainingZonePages fetches the pages following the given first page,
+// returning them in order. The first failing page cancels the requests for
+// the others.
+func (p *Provider) listRemainingZonePages(ctx context.Context, search string, first *ListZonesResponse) ([][]*Zone, error) {
+	count := (first.TotalItems + zonesPerPage - 1) / zonesPerPage
+
+	// Without a usable total, fall back to fetching page by page.
+	if count < 2 {
+		var pages [][]*Zone
+
+		for page := 2; ; page++ {
+			results, err := p.client.ListZones(ctx, ListZonesRequest{
+				Page:    page,
+				PerPage: zonesPerPage,
+				Domain:  search,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			pages = append(pages, results.Items)
+
+			if !results.HasMoreItems {
+				return pages, nil
+			}
+		}
+	}
+
+	pages := make([][]*Zone, count-1)
+
+	group, groupCtx := errgroup.WithContext(ctx)
+	group.SetLimit(maxConcurrentZonePages)
+
+	for i := range pages {
+		group.Go(func() error {
+			results, err := p.client.ListZones(groupCtx, ListZonesRequest{
+				Page:    i + 2,
+				PerPage: zonesPerPage,
+				Domain:  search,
+			})
+			if err != nil {
+				return err
+			}
+
+			pages[i] = results.Items
+
+			return nil
+		})
+	}
+
+	if err := group.Wait(); err != nil {
+		return nil, err
+	}
+
+	return pages, nil
 }
 
 // extractRecordComponents extracts the record name and zone from a given DNS name
@@ -591,7 +954,12 @@ func (p *Provider) fetchZones(ctx context.Context) ([]*Zone, error) {
 // the function returns the record name, zone, and true as the third argument.
 func extractRecordComponents(zones []string, dnsName string) (string, string, bool) {
 	for _, zone := range zones {
-		if strings.HasSuffix(dnsName, zone) {
+		// Records at the zone apex have an empty record name.
+		if dnsName == zone {
+			return "", zone, true
+		}
+
+		if strings.HasSuffix(dnsName, "."+zone) {
 			return dnsName[:len(dnsName)-len(zone)-1], zone, true
 		}
 	}
@@ -599,7 +967,42 @@ func extractRecordComponents(zones []string, dnsName string) (string, string, bo
 	return "", "", false
 }
 
-func getDomainFilter(options Options) endpoint.DomainFilterInterface {
+// zoneMatchesFilter reports whether the zone may hold records matching the
+// domain filter, either because the zone itself matches or because it is the
+// parent of an included domain. Regular expressions cannot be checked for
+// parents, so with those the zone itself has to match.
+func (p *Provider) zoneMatchesFilter(domain string) bool {
+	if p.Options.IncludeDomainsRegexp != "" || p.Options.ExcludeDomainsRegexp != "" {
+		return p.filter.Match(domain)
+	}
+
+	return p.filter.Match(domain) || p.filter.MatchParent(domain)
+}
+
+// zoneSearches returns the terms zones are searched for in the API, or none
+// if all zones have to be listed. Zones holding an included domain may be
+// parents of it, so the search is for the last two labels of the domain,
+// which are part of the name of every such zone. The results are matched
+// against the domain filter afterwards.
+func zoneSearches(options Options) []string {
+	if options.IncludeDomainsRegexp != "" || options.ExcludeDomainsRegexp != "" {
+		return nil
+	}
+
+	var searches []string
+	for _, domain := range options.IncludeDomains {
+		labels := strings.Split(strings.Trim(strings.ToLower(domain), "."), ".")
+		if len(labels) < 2 {
+			return nil
+		}
+
+		searches = append(searches, strings.Join(labels[len(labels)-2:], "."))
+	}
+
+	return lo.Uniq(searches)
+}
+
+func getDomainFilter(options Options) endpoint.DomainFilter {
 	if options.ExcludeDomainsRegexp != "" || options.IncludeDomainsRegexp != "" {
 		return endpoint.NewRegexDomainFilter(
 			regexp.MustCompile(options.IncludeDomainsRegexp),
@@ -1,19 +1,217 @@
 package bunny
 
 import (
+	"strconv"
+
 	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/provider"
 )
 
-func recordToEndpoint(domain string, record *Record) *endpoint.Endpoint {
+// labelMonitorStatus is the endpoint label holding the status of a monitored
+// record as reported by Bunny.net.
+const labelMonitorStatus = "bunny-monitor-status"
+
+// recordToEndpoint converts a Bunny.net record into an external-dns endpoint.
+// Records that cannot be represented as an endpoint are reported with false
+// as the second return value and should be skipped by the caller.
+func recordToEndpoint(domain string, record *Record) (*endpoint.Endpoint, bool) {
+	recordType, ok := endpointRecordType(record)
+	if !ok {
+		return nil, false
+	}
+
 	ep := endpoint.NewEndpointWithTTL(
-		record.Name+"."+domain,
-		record.Type.String(),
+		dnsNameOf(domain, record.Name),
+		recordType,
 		endpoint.TTL(record.TTLSeconds),
-		record.Value,
+		endpointTarget(record),
 	)
+	if ep == nil {
+		return nil, false
+	}
 
 	ps := providerSpecificOptionsFromRecord(record)
 	ps.ApplyToEndpoint(ep)
 
-	return ep
+	// The monitor status is surfaced as a label rather than a provider
+	// specific property, as it is not something that can be requested
+	// and would otherwise cause external-dns to plan updates.
+	if record.MonitorType != MonitorTypeNone {
+		ep.Labels[labelMonitorStatus] = record.MonitorStatus.String()
+	}
+
+	return ep, true
+}
+
+// endpointRecordType returns the external-dns record type used to represent
+// the given record. Bunny.net specific record types are presented as one of
+// the standard types so that external-dns can plan changes for them.
+func endpointRecordType(record *Record) (string, bool) {
+	switch record.Type {
+	case RecordTypeRDR:
+		return endpoint.RecordTypeCNAME, true
+	case RecordTypeFlatten:
+		// Flattened records are how Bunny.net stores a CNAME at the zone
+		// apex, present them the same way they were requested.
+		return endpoint.RecordTypeCNAME, true
+	case RecordTypeSCR:
+		return endpoint.RecordTypeCNAME, true
+	}
+
+	return record.Type.String(), provider.SupportedRecordType(record.Type.String())
+}
+
+// endpointTarget returns the endpoint target used to represent the given
+// record. Scriptable records are identified by the script they are bound to.
+func endpointTarget(record *Record) string {
+	if record.Type == RecordTypeSCR {
+		return strconv.FormatInt(record.ScriptID, 10)
+	}
+
+	return record.Value
+}
+
+// recordTypeFromEndpoint returns the Bunny.net record type that should be
+// used to store the given endpoint. The record name is the name relative to
+// the zone, which is empty for records at the zone apex.
+func recordTypeFromEndpoint(ep *endpoint.Endpoint, recordName string, opts providerSpecificOptions) RecordType {
+	if opts.IsRedirect() {
+		return RecordTypeRDR
+	}
+
+	if opts.IsScript() {
+		return RecordTypeSCR
+	}
+
+	// A CNAME is not allowed at the zone apex, so it is transparently
+	// flattened instead.
+	if ep.RecordType == endpoint.RecordTypeCNAME && recordName == "" {
+		return RecordTypeFlatten
+	}
+
+	return RecordTypeFromString(ep.RecordType)
+}
+
+// recordValueFromEndpoint returns the Bunny.net record value that should be
+// used to store the given endpoint.
+func recordValueFromEndpoint(ep *endpoint.Endpoint, opts providerSpecificOptions) string {
+	if opts.IsRedirect() {
+		return opts.Redirect
+	}
+
+	if opts.IsScript() {
+		return strconv.FormatInt(opts.ScriptID, 10)
+	}
+
+	return ep.Targets[0]
+}
+
+// canonicalizeEndpoint rewrites a desired endpoint into the shape returned by
+// Records, so that external-dns does not plan changes for records that are
+// already up to date.
+func canonicalizeEndpoint(ep *endpoint.Endpoint) error {
+	opts, err := providerSpecificOptionsFromEndpoint(ep)
+	if err != nil {
+		return err
+	}
+
+	if opts.IsRedirect() {
+		ep.RecordType = endpoint.RecordTypeCNAME
+		ep.Targets = endpoint.NewTargets(opts.Redirect)
+	}
+
+	if opts.IsScript() {
+		ep.RecordType = endpoint.RecordTypeCNAME
+		ep.Targets = endpoint.NewTargets(strconv.FormatInt(opts.ScriptID, 10))
+	}
+
+	opts.ApplyToEndpoint(ep)
+
+	return nil
+}
+
+// createRequestFromRecord returns the request that recreates the given record.
+func createRequestFromRecord(r *Record) CreateRecordRequest {
+	return CreateRecordRequest{
+		Type:                 r.Type,
+		TTLSeconds:           r.TTLSeconds,
+		Value:                r.Value,
+		Name:                 r.Name,
+		MonitorType:          r.MonitorType,
+		Weight:               r.Weight,
+		Disabled:             r.Disabled,
+		RedirectStatusCode:   r.RedirectStatusCode,
+		RedirectPreservePath: r.RedirectPreservePath,
+		ScriptID:             r.ScriptID,
+		EnvironmentVariables: r.EnvironmentVariables,
+		Comment:              r.Comment,
+		Priority:             r.Priority,
+		Port:                 r.Port,
+		Flags:                r.Flags,
+		Tag:                  r.Tag,
+	}
+}
+
+// updateRequestFromRecord returns the request that updates an existing record
+// to match the given record.
+func updateRequestFromRecord(r *Record) UpdateRecordRequest {
+	return UpdateRecordRequest{
+		Type:                 r.Type,
+		TTLSeconds:           r.TTLSeconds,
+		Value:                r.Value,
+		MonitorType:          r.MonitorType,
+		Weight:               r.Weight,
+		Disabled:             r.Disabled,
+		RedirectStatusCode:   r.RedirectStatusCode,
+		RedirectPreservePath: r.RedirectPreservePath,
+		ScriptID:             r.ScriptID,
+		EnvironmentVariables: r.EnvironmentVariables,
+		Comment:              r.Comment,
+		Priority:             r.Priority,
+		Port:                 r.Port,
+		Flags:                r.Flags,
+		Tag:                  r.Tag,
+	}
+}
+
+// recordsEqual reports whether two records hold the same settings. The record
+// ID and values reported by Bunny.net, such as the monitor status, are not
+// compared.
+func recordsEqual(a, b *Record) bool {
+	return a.Type == b.Type &&
+		a.Name == b.Name &&
+		a.TTLSeconds == b.TTLSeconds &&
+		a.Value == b.Value &&
+		a.MonitorType == b.MonitorType &&
+		a.Weight == b.Weight &&
+		a.Disabled == b.Disabled &&
+		a.RedirectStatusCode == b.RedirectStatusCode &&
+		a.RedirectPreservePath == b.RedirectPreservePath &&
+		a.ScriptID == b.ScriptID &&
+		formatScriptEnv(sortScriptEnv(a.EnvironmentVariables)) == formatScriptEnv(sortScriptEnv(b.EnvironmentVariables)) &&
+		a.Comment == b.Comment &&
+		a.Priority == b.Priority &&
+		a.Port == b.Port &&
+		a.Flags == b.Flags &&
+		a.Tag == b.Tag
+}
+
+// recordFromUpdateRequest returns the given record with the given update
+// request applied to it.
+func recordFromUpdateRequest(previous *Record, r UpdateRecordRequest) *Record {
+	record := *previous
+
+	record.Type = r.Type
+	record.TTLSeconds = r.TTLSeconds
+	record.Value = r.Value
+	record.MonitorType = r.MonitorType
+	record.Weight = r.Weight
+	record.Disabled = r.Disabled
+	record.RedirectStatusCode = r.RedirectStatusCode
+	record.RedirectPreservePath = r.RedirectPreservePath
+	record.ScriptID = r.ScriptID
+	record.EnvironmentVariables = r.EnvironmentVariables
+	record.Comment = r.Comment
+
+	return &record
 }
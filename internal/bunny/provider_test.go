@@ -0,0 +1,67 @@
+package bunny
+
+import (
+	"context"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+func TestApplyChangesRejectsStaleRecords(t *testing.T) {
+	p := newTestProvider(Options{}, &Zone{ID: 1, Domain: "example.com"})
+	p.stale.Store(true)
+
+	changes := &plan.Changes{
+		Create: []*endpoint.Endpoint{endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "1.1.1.1")},
+	}
+
+	if err := p.ApplyChanges(context.Background(), changes); err == nil {
+		t.Fatal("ApplyChanges() succeeded while serving stale records")
+	}
+
+	if calls := p.client.(*fakeClient).calls; len(calls) != 0 {
+		t.Errorf("client calls = %v, want none", calls)
+	}
+}
+
+func TestZoneMatchesFilter(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		zones   map[string]bool
+	}{
+		{
+			name:    "no filter",
+			options: Options{},
+			zones:   map[string]bool{"example.com": true, "example.org": true},
+		},
+		{
+			name:    "included subdomain",
+			options: Options{IncludeDomains: []string{"app.example.com"}},
+			zones:   map[string]bool{"example.com": true, "app.example.com": true, "example.org": false},
+		},
+		{
+			name:    "include regexp",
+			options: Options{IncludeDomainsRegexp: `(^|\.)example\.com$`},
+			zones:   map[string]bool{"example.com": true, "example.org": false},
+		},
+		{
+			name:    "exclude regexp",
+			options: Options{ExcludeDomainsRegexp: `example\.org$`},
+			zones:   map[string]bool{"example.com": true, "example.org": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &Provider{Options: tt.options, filter: getDomainFilter(tt.options)}
+
+			for zone, want := range tt.zones {
+				if got := p.zoneMatchesFilter(zone); got != want {
+					t.Errorf("zoneMatchesFilter(%q) = %v, want %v", zone, got, want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package bunny
+
+import (
+	"strings"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+// recordTypeTemplate is replaced with the lowercased record type in the
+// prefix and suffix of the TXT registry.
+const recordTypeTemplate = "%{record_type}"
+
+// registryNaming derives the names the external-dns TXT registry stores the
+// ownership of a record under. It has to be configured with the same TXT
+// prefix, suffix and wildcard replacement as external-dns.
+type registryNaming struct {
+	prefix              string
+	suffix              string
+	wildcardReplacement string
+}
+
+func newRegistryNaming(options Options) registryNaming {
+	return registryNaming{
+		prefix:              strings.ToLower(options.TXTPrefix),
+		suffix:              strings.ToLower(options.TXTSuffix),
+		wildcardReplacement: strings.ToLower(options.TXTWildcardReplacement),
+	}
+}
+
+// names returns the names of the registry records that may hold the
+// ownership of the endpoint, in the current format including the record type
+// and in the legacy format without it.
+func (n registryNaming) names(ep *endpoint.Endpoint) []string {
+	recordType := strings.ToLower(ep.RecordType)
+
+	labels := strings.SplitN(strings.ToLower(strings.TrimSuffix(ep.DNSName, ".")), ".", 2)
+	if n.wildcardReplacement != "" && labels[0] == "*" {
+		labels[0] = n.wildcardReplacement
+	}
+
+	name := func(prefix, first, suffix string) string {
+		if len(labels) < 2 {
+			return prefix + first + suffix
+		}
+
+		return prefix + first + suffix + "." + labels[1]
+	}
+
+	// Without the template in the affixes, the record type is put in
+	// front of the first label.
+	first := labels[0]
+	if !strings.Contains(n.prefix, recordTypeTemplate) && !strings.Contains(n.suffix, recordTypeTemplate) {
+		first = recordType + "-" + first
+	}
+
+	return []string{
+		name(strings.ReplaceAll(n.prefix, recordTypeTemplate, recordType), first, strings.ReplaceAll(n.suffix, recordTypeTemplate, recordType)),
+		name(strings.ReplaceAll(n.prefix, recordTypeTemplate, ""), labels[0], strings.ReplaceAll(n.suffix, recordTypeTemplate, "")),
+	}
+}
+
+// registryIndex holds the names of the registry records in a set of
+// endpoints, so the ownership of other endpoints can be looked up.
+type registryIndex map[string]bool
+
+// registryIndex returns the index of the registry records in the zone.
+func (p *Provider) registryIndex(zone *Zone) registryIndex {
+	idx := make(registryIndex)
+	for _, record := range zone.Records {
+		if record.Type != RecordTypeTXT {
+			continue
+		}
+
+		if ep, ok := recordToEndpoint(zone.Domain, record); ok {
+			idx.add(ep)
+		}
+	}
+
+	return idx
+}
+
+// add adds the endpoint to the index if it is a registry record, and reports
+// whether it was.
+func (idx registryIndex) add(ep *endpoint.Endpoint) bool {
+	if !isRegistryRecord(ep) {
+		return false
+	}
+
+	idx[strings.ToLower(strings.TrimSuffix(ep.DNSName, "."))] = true
+
+	return true
+}
+
+// owns reports whether a registry record in the index holds the ownership of
+// the endpoint.
+func (idx registryIndex) owns(naming registryNaming, ep *endpoint.Endpoint) bool {
+	for _, name := range naming.names(ep) {
+		if idx[name] {
+			return true
+		}
+	}
+
+	return false
+}
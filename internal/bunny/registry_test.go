@@ -0,0 +1,90 @@
+package bunny
+
+import (
+	"slices"
+	"testing"
+
+	"sigs.k8s.io/external-dns/endpoint"
+)
+
+func TestRegistryNamingNames(t *testing.T) {
+	tests := []struct {
+		name    string
+		options Options
+		ep      *endpoint.Endpoint
+		want    []string
+	}{
+		{
+			name: "no affix",
+			ep:   endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			want: []string{"a-www.example.com", "www.example.com"},
+		},
+		{
+			name:    "prefix",
+			options: Options{TXTPrefix: "test-"},
+			ep:      endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeCNAME, "example.net"),
+			want:    []string{"test-cname-www.example.com", "test-www.example.com"},
+		},
+		{
+			name:    "suffix",
+			options: Options{TXTSuffix: "-owner"},
+			ep:      endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			want:    []string{"a-www-owner.example.com", "www-owner.example.com"},
+		},
+		{
+			name:    "record type template",
+			options: Options{TXTPrefix: "%{record_type}.own."},
+			ep:      endpoint.NewEndpoint("www.example.com", endpoint.RecordTypeAAAA, "::1"),
+			want:    []string{"aaaa.own.www.example.com", ".own.www.example.com"},
+		},
+		{
+			name:    "wildcard replacement",
+			options: Options{TXTWildcardReplacement: "any"},
+			ep:      endpoint.NewEndpoint("*.example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			want:    []string{"a-any.example.com", "any.example.com"},
+		},
+		{
+			name:    "mixed case",
+			options: Options{TXTPrefix: "Test-"},
+			ep:      endpoint.NewEndpoint("WWW.Example.com", endpoint.RecordTypeA, "1.2.3.4"),
+			want:    []string{"test-a-www.example.com", "test-www.example.com"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := newRegistryNaming(tt.options).names(tt.ep)
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("names() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestManagedRecordsWithPrefix(t *testing.T) {
+	p := &Provider{registry: newRegistryNaming(Options{TXTPrefix: "test-"})}
+
+	zone := &Zone{
+		ID:     1,
+		Domain: "example.com",
+		Records: []*Record{
+			{ID: 1, Name: "www", Type: RecordTypeA, Value: "1.2.3.4"},
+			{ID: 2, Name: "test-a-www", Type: RecordTypeTXT, Value: `"heritage=external-dns,external-dns/owner=default"`},
+			{ID: 3, Name: "manual", Type: RecordTypeA, Value: "1.2.3.5"},
+			{ID: 4, Name: "tagged", Type: RecordTypeA, Value: "1.2.3.6", Comment: "heritage=external-dns,external-dns/owner=default"},
+		},
+	}
+
+	got := p.managedRecords(zone)
+
+	want := map[int64]bool{1: false, 2: true, 4: false}
+	if len(got) != len(want) {
+		t.Fatalf("managedRecords() = %v, want %v", got, want)
+	}
+
+	for id, registry := range want {
+		if r, ok := got[id]; !ok || r != registry {
+			t.Errorf("managedRecords()[%d] = %v, %v, want %v", id, r, ok, registry)
+		}
+	}
+}
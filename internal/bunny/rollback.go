@@ -0,0 +1,89 @@
+package bunny
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// rollbackTimeout bounds how long rolling back a batch may take. Rollbacks
+// run detached from the request that applied the batch, as a request that
+// timed out or was cancelled is the most likely reason to roll back.
+const rollbackTimeout = 2 * time.Minute
+
+// appliedChange records a change that has been applied to a record, with
+// enough information to revert it.
+type appliedChange struct {
+	Kind     string
+	ZoneID   int64
+	RecordID int64
+	// Previous is the record before the change, nil for creates.
+	Previous *Record
+	// Record is the record after the change, nil for deletes.
+	Record *Record
+}
+
+// revert applies the inverse of the change.
+func (c *appliedChange) revert(ctx context.Context, client Client) error {
+	switch c.Kind {
+	case ChangeKindCreate:
+		return client.DeleteRecord(ctx, c.ZoneID, c.RecordID)
+	case ChangeKindUpdate:
+		return client.UpdateRecord(ctx, c.ZoneID, c.RecordID, updateRequestFromRecord(c.Previous))
+	case ChangeKindDelete:
+		_, err := client.CreateRecord(ctx, strconv.FormatInt(c.ZoneID, 10), createRequestFromRecord(c.Previous))
+		return err
+	}
+
+	return fmt.Errorf("unknown change kind %q", c.Kind)
+}
+
+func (c *appliedChange) logAttrs() []any {
+	attrs := []any{
+		slog.String("kind", c.Kind),
+		slog.Int64("zone_id", c.ZoneID),
+		slog.Int64("record_id", c.RecordID),
+	}
+
+	if c.Previous != nil {
+		attrs = append(attrs, slog.Group("previous",
+			slog.String("name", c.Previous.Name),
+			slog.String("type", c.Previous.Type.String()),
+			slog.String("value", c.Previous.Value),
+			slog.Int("ttl", c.Previous.TTLSeconds),
+		))
+	}
+
+	return attrs
+}
+
+// rollback reverts the given changes in reverse order on a best-effort
+// basis. Every change is attempted, and the changes that could not be
+// reverted are reported in the returned error.
+func (p *Provider) rollback(ctx context.Context, applied []*appliedChange) error {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), rollbackTimeout)
+	defer cancel()
+
+	slog.WarnContext(ctx, "Rolling back applied changes.",
+		slog.Int("changes", len(applied)))
+
+	var errs []error
+	for _, change := range slices.Backward(applied) {
+		if err := change.revert(ctx, p.client); err != nil {
+			slog.ErrorContext(ctx, "Failed to roll back change.",
+				append(change.logAttrs(), slog.Any("error", err))...)
+
+			errs = append(errs, fmt.Errorf("failed to roll back %s of record %d in zone %d: %w", change.Kind, change.RecordID, change.ZoneID, err))
+
+			continue
+		}
+
+		slog.InfoContext(ctx, "Rolled back change.", change.logAttrs()...)
+	}
+
+	return errors.Join(errs...)
+}
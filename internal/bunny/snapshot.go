@@ -0,0 +1,342 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/samber/oops"
+	"sigs.k8s.io/external-dns/endpoint"
+	"sigs.k8s.io/external-dns/plan"
+)
+
+const (
+	snapshotVersion = 1
+
+	snapshotPrefix = "snapshot-"
+	snapshotSuffix = ".json"
+)
+
+// Snapshot is the state of a set of zones at a point in time, taken before
+// changes are applied to them.
+type Snapshot struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"created_at"`
+	Zones     []*Zone   `json:"zones"`
+}
+
+// ReadSnapshot reads a snapshot previously written by the provider.
+func ReadSnapshot(path string) (*Snapshot, error) {
+	errs := oops.In("Snapshot").
+		With("path", path).
+		Span("ReadSnapshot")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to read snapshot")
+	}
+
+	var snapshot Snapshot
+	if err := json.Unmarshal(data, &snapshot); err != nil {
+		return nil, errs.Wrapf(err, "failed to decode snapshot")
+	}
+
+	if snapshot.Version != snapshotVersion {
+		return nil, errs.Errorf("unsupported snapshot version %d", snapshot.Version)
+	}
+
+	return &snapshot, nil
+}
+
+// snapshotZones writes a snapshot of the zones affected by the given changes
+// to the snapshot directory and prunes old snapshots. Snapshots are disabled
+// when no directory is configured.
+func (p *Provider) snapshotZones(ctx context.Context, changes *plan.Changes) error {
+	if p.Options.SnapshotDir == "" {
+		return nil
+	}
+
+	errs := oops.In("Provider").
+		With("dir", p.Options.SnapshotDir).
+		Span("snapshotZones")
+
+	zones, err := p.zones(ctx)
+	if err != nil {
+		return errs.Wrapf(err, "failed to fetch zones")
+	}
+
+	var domainNames []string
+	for _, zone := range zones {
+		domainNames = append(domainNames, zone.Domain)
+	}
+
+	affected := make(map[string]bool)
+	for _, endpoints := range [][]*endpoint.Endpoint{changes.Create, changes.UpdateNew, changes.Delete} {
+		for _, ep := range endpoints {
+			if _, domainName, ok := extractRecordComponents(domainNames, ep.DNSName); ok {
+				affected[domainName] = true
+			}
+		}
+	}
+
+	snapshot := &Snapshot{
+		Version:   snapshotVersion,
+		CreatedAt: time.Now().UTC(),
+	}
+
+	for _, zone := range zones {
+		if affected[zone.Domain] {
+			snapshot.Zones = append(snapshot.Zones, zone)
+		}
+	}
+
+	if len(snapshot.Zones) == 0 {
+		return nil
+	}
+
+	path, err := writeSnapshot(p.Options.SnapshotDir, snapshot)
+	if err != nil {
+		return errs.Wrapf(err, "failed to write snapshot")
+	}
+
+	slog.InfoContext(ctx, "Snapshot written.",
+		slog.String("path", path),
+		slog.Int("zones", len(snapshot.Zones)))
+
+	if err := pruneSnapshots(p.Options.SnapshotDir, p.Options.SnapshotRetention); err != nil {
+		// A failure to prune is not a reason to refuse the changes.
+		slog.WarnContext(ctx, "Failed to prune snapshots.",
+			slog.String("dir", p.Options.SnapshotDir),
+			slog.Any("error", err))
+	}
+
+	return nil
+}
+
+// writeSnapshot atomically writes the snapshot into the given directory and
+// returns the path of the written file.
+func writeSnapshot(dir string, snapshot *Snapshot) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(snapshot, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	name := snapshotPrefix + snapshot.CreatedAt.Format("20060102T150405.000000000Z") + snapshotSuffix
+	path := filepath.Join(dir, name)
+
+	if err := writeFileAtomic(path, data); err != nil {
+		return "", err
+	}
+
+	return path, nil
+}
+
+// writeFileAtomic writes data to a temporary file next to path and renames it
+// into place, so readers never observe a partially written file.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+// pruneSnapshots removes all but the newest keep snapshots from the given
+// directory. A keep of zero or less retains all snapshots.
+func pruneSnapshots(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasPrefix(entry.Name(), snapshotPrefix) || !strings.HasSuffix(entry.Name(), snapshotSuffix) {
+			continue
+		}
+
+		names = append(names, entry.Name())
+	}
+
+	if len(names) <= keep {
+		return nil
+	}
+
+	// Snapshot names embed their creation time, so they sort chronologically.
+	slices.Sort(names)
+
+	for _, name := range names[:len(names)-keep] {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RestoreSnapshot reverts the zones in the snapshot to the state they were in
+// when the snapshot was taken. The live state of each zone is compared against
+// the snapshot, records created since are deleted, records deleted since are
+// recreated and records changed since are updated back. Only records managed
+// by external-dns are deleted, and unmanaged records are not updated while
+// they are protected. In dry-run mode the changes are only logged.
+func (p *Provider) RestoreSnapshot(ctx context.Context, snapshot *Snapshot) error {
+	errs := oops.In("Provider").
+		With("created_at", snapshot.CreatedAt).
+		Span("RestoreSnapshot")
+
+	zones, err := p.fetchZones(ctx)
+	if err != nil {
+		return errs.Wrapf(err, "failed to fetch zones")
+	}
+
+	defer p.invalidateZones()
+
+	live := make(map[int64]*Zone)
+	for _, zone := range zones {
+		live[zone.ID] = zone
+	}
+
+	for _, saved := range snapshot.Zones {
+		current, ok := live[saved.ID]
+		if !ok {
+			return errs.Errorf("zone %q (%d) no longer exists", saved.Domain, saved.ID)
+		}
+
+		if err := p.restoreZone(ctx, saved, current); err != nil {
+			return errs.Wrapf(err, "failed to restore zone %q", saved.Domain)
+		}
+	}
+
+	return nil
+}
+
+func (p *Provider) restoreZone(ctx context.Context, saved *Zone, current *Zone) error {
+	savedRecords := make(map[int64]*Record)
+	for _, record := range saved.Records {
+		savedRecords[record.ID] = record
+	}
+
+	currentRecords := make(map[int64]*Record)
+	for _, record := range current.Records {
+		currentRecords[record.ID] = record
+	}
+
+	managed := p.managedRecords(current)
+
+	for _, record := range current.Records {
+		if _, ok := savedRecords[record.ID]; ok {
+			continue
+		}
+
+		// Records created outside of external-dns since the snapshot was
+		// taken are not the provider's to remove.
+		if _, ok := managed[record.ID]; !ok && !p.isUnmanagedAllowed(dnsNameOf(current.Domain, record.Name)) {
+			logRestore(ctx, "Restore: keeping record not managed by external-dns.", p.Options.DryRun, saved, record)
+			continue
+		}
+
+		logRestore(ctx, "Restore: delete record.", p.Options.DryRun, saved, record)
+
+		if p.Options.DryRun {
+			continue
+		}
+
+		if err := p.client.DeleteRecord(ctx, saved.ID, record.ID); err != nil {
+			return err
+		}
+	}
+
+	for _, record := range saved.Records {
+		existing, ok := currentRecords[record.ID]
+		switch {
+		case !ok:
+			logRestore(ctx, "Restore: create record.", p.Options.DryRun, saved, record)
+
+			if p.Options.DryRun {
+				continue
+			}
+
+			if _, err := p.client.CreateRecord(ctx, strconv.FormatInt(saved.ID, 10), createRequestFromRecord(record)); err != nil {
+				return err
+			}
+
+		case !recordsEqual(existing, record):
+			if _, ok := managed[existing.ID]; !ok && p.Options.ProtectUnmanaged && !p.isUnmanagedAllowed(dnsNameOf(current.Domain, existing.Name)) {
+				logRestore(ctx, "Restore: refusing to update record not managed by external-dns.", p.Options.DryRun, saved, existing)
+				continue
+			}
+
+			logRestore(ctx, "Restore: update record.", p.Options.DryRun, saved, record)
+
+			if p.Options.DryRun {
+				continue
+			}
+
+			if err := p.client.UpdateRecord(ctx, saved.ID, record.ID, updateRequestFromRecord(record)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func logRestore(ctx context.Context, msg string, dryRun bool, zone *Zone, record *Record) {
+	if dryRun {
+		msg = "DRY RUN: " + msg
+	}
+
+	slog.InfoContext(ctx, msg,
+		slog.String("zone", zone.Domain),
+		slog.Int64("zone_id", zone.ID),
+		slog.Group("record",
+			slog.Int64("id", record.ID),
+			slog.String("name", record.Name),
+			slog.String("type", record.Type.String()),
+			slog.String("value", record.Value),
+			slog.Int("ttl", record.TTLSeconds),
+		))
+}
+
+// String returns a short description of the snapshot.
+func (s *Snapshot) String() string {
+	domains := make([]string, 0, len(s.Zones))
+	for _, zone := range s.Zones {
+		domains = append(domains, zone.Domain)
+	}
+
+	return fmt.Sprintf("snapshot of %s taken at %s", strings.Join(domains, ", "), s.CreatedAt.Format(time.RFC3339))
+}
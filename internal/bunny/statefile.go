@@ -0,0 +1,152 @@
+package bunny
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/samber/oops"
+)
+
+const stateFileVersion = 1
+
+// stateFile is the zone cache as persisted to disk, so a restarted webhook
+// can serve records before the zones have been fetched again. It also holds
+// the records last applied by the provider, so drift is still detected after
+// a restart.
+type stateFile struct {
+	Version   int                     `json:"version"`
+	FetchedAt time.Time               `json:"fetched_at"`
+	Zones     []*Zone                 `json:"zones"`
+	Applied   map[int64]appliedRecord `json:"applied,omitempty"`
+}
+
+// readStateFile reads the state file at the given path. A missing file is
+// not an error and returns nil.
+func readStateFile(path string) (*stateFile, error) {
+	errs := oops.In("StateFile").
+		With("path", path).
+		Span("readStateFile")
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, errs.Wrapf(err, "failed to read state file")
+	}
+
+	var state stateFile
+	if err := json.Unmarshal(data, &state); err != nil {
+		return nil, errs.Wrapf(err, "failed to decode state file")
+	}
+
+	if state.Version != stateFileVersion {
+		return nil, errs.Errorf("unsupported state file version %d", state.Version)
+	}
+
+	return &state, nil
+}
+
+// writeStateFile atomically writes the state file to the given path.
+func writeStateFile(path string, state *stateFile) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// loadStateFile seeds the zone cache from the state file. It reports whether
+// the cache was seeded, in which case the zones only need to be refreshed in
+// the background. Seeded zones are only served by Records, everything else
+// waits for the live zones.
+func (p *Provider) loadStateFile(ctx context.Context) bool {
+	state, err := readStateFile(p.Options.StateFile)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to load the state file, fetching zones instead.",
+			slog.String("path", p.Options.StateFile),
+			slog.Any("error", err))
+
+		return false
+	}
+
+	if state == nil {
+		return false
+	}
+
+	for _, zone := range state.Zones {
+		p.cacheZone(zone)
+	}
+
+	p.cache.seed(state.Zones, state.FetchedAt)
+
+	for id, applied := range state.Applied {
+		p.lastApplied.Store(id, applied)
+	}
+
+	slog.InfoContext(ctx, "Loaded zones from the state file.",
+		slog.String("path", p.Options.StateFile),
+		slog.Int("zones", len(state.Zones)),
+		slog.Duration("age", time.Since(state.FetchedAt).Round(time.Second)))
+
+	return true
+}
+
+// refreshSeededZones replaces the zones loaded from the state file with the
+// live zones. If they cannot be fetched, the loaded zones are no longer
+// served by Records, so the next reads go to the API.
+func (p *Provider) refreshSeededZones(ctx context.Context) {
+	if _, err := p.fetchZones(ctx); err != nil {
+		slog.ErrorContext(ctx, "Failed to refresh zones loaded from the state file.",
+			slog.Any("error", err))
+
+		p.cache.endSeed()
+	}
+}
+
+// persistZones writes the cached zones to the state file, if one is
+// configured. Failures are logged only, as the state file is an optimization.
+func (p *Provider) persistZones() {
+	if p.Options.StateFile == "" {
+		return
+	}
+
+	// Writes are serialized and always write the current state of the
+	// cache, so a slow write never overwrites a newer one.
+	p.persistMu.Lock()
+	defer p.persistMu.Unlock()
+
+	zones, fetchedAt := p.cache.current()
+	if zones == nil {
+		return
+	}
+
+	applied := make(map[int64]appliedRecord)
+	p.lastApplied.Range(func(id int64, record appliedRecord) bool {
+		applied[id] = record
+		return true
+	})
+
+	err := writeStateFile(p.Options.StateFile, &stateFile{
+		Version:   stateFileVersion,
+		FetchedAt: fetchedAt,
+		Zones:     zones,
+		Applied:   applied,
+	})
+	if err != nil {
+		slog.Warn("Failed to write the state file.",
+			slog.String("path", p.Options.StateFile),
+			slog.Any("error", err))
+	}
+}
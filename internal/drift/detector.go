@@ -0,0 +1,134 @@
+package drift
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/bunny"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+type Options struct {
+	Enabled  bool          `env:"ENABLED, default=false"`
+	Interval time.Duration `env:"INTERVAL, default=5m"`
+	Revert   bool          `env:"REVERT, default=false"`
+}
+
+// Checker detects records whose state drifted from the state last applied.
+type Checker interface {
+	DetectDrift(ctx context.Context, revert bool) ([]bunny.Drift, error)
+}
+
+var (
+	driftedRecords = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "drifted_records",
+		Help:      "Number of records whose state in Bunny.net differs from the state last applied, by zone.",
+	}, []string{"zone"})
+
+	driftReverts = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "drift_reverts_total",
+		Help:      "Number of drifted records reverted to the state last applied, by zone and result.",
+	}, []string{"zone", "result"})
+
+	driftChecks = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "drift_checks_total",
+		Help:      "Number of drift checks, by result.",
+	}, []string{"result"})
+)
+
+// Detector periodically checks for records that were changed outside of
+// external-dns, e.g. in the Bunny.net dashboard, and optionally reverts them.
+type Detector struct {
+	Options Options
+	Checker Checker
+}
+
+func (d *Detector) Serve(ctx context.Context) error {
+	if d.Checker == nil {
+		return fmt.Errorf("checker is required")
+	}
+
+	ticker := time.NewTicker(d.Options.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+			d.check(ctx)
+		}
+	}
+}
+
+func (d *Detector) check(ctx context.Context) {
+	drifts, err := d.Checker.DetectDrift(ctx, d.Options.Revert)
+	if err != nil {
+		driftChecks.WithLabelValues("error").Inc()
+
+		slog.ErrorContext(ctx, "Failed to check for drifted records.",
+			slog.Any("error", err))
+
+		return
+	}
+
+	driftChecks.WithLabelValues("success").Inc()
+	driftedRecords.Reset()
+
+	for _, drift := range drifts {
+		driftedRecords.WithLabelValues(drift.Zone).Inc()
+
+		attrs := []any{
+			slog.String("zone", drift.Zone),
+			slog.Int64("zone_id", drift.ZoneID),
+			slog.Group("expected",
+				slog.Int64("id", drift.Expected.ID),
+				slog.String("name", drift.Expected.Name),
+				slog.String("type", drift.Expected.Type.String()),
+				slog.String("value", drift.Expected.Value),
+				slog.Int("ttl", drift.Expected.TTLSeconds),
+			),
+		}
+
+		if drift.Actual != nil {
+			attrs = append(attrs, slog.Group("actual",
+				slog.String("type", drift.Actual.Type.String()),
+				slog.String("value", drift.Actual.Value),
+				slog.Int("ttl", drift.Actual.TTLSeconds),
+				slog.Bool("disabled", drift.Actual.Disabled),
+			))
+		} else {
+			attrs = append(attrs, slog.Bool("deleted", true))
+		}
+
+		slog.WarnContext(ctx, "Record drifted from the state last applied.", attrs...)
+
+		if !d.Options.Revert {
+			continue
+		}
+
+		if drift.Err != nil {
+			driftReverts.WithLabelValues(drift.Zone, "error").Inc()
+
+			slog.ErrorContext(ctx, "Failed to revert drifted record.",
+				append(attrs, slog.Any("error", drift.Err))...)
+
+			continue
+		}
+
+		if drift.Reverted {
+			driftReverts.WithLabelValues(drift.Zone, "success").Inc()
+
+			slog.InfoContext(ctx, "Reverted drifted record.", attrs...)
+		}
+	}
+
+	slog.DebugContext(ctx, "Checked for drifted records.",
+		slog.Int("drifted", len(drifts)))
+}
@@ -9,6 +9,8 @@ import (
 	"net/http"
 	"sync/atomic"
 	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 )
 
 type Options struct {
@@ -24,6 +26,11 @@ func (o *Options) Addr() string {
 
 type Server struct {
 	Options Options
+
+	// DegradedFunc optionally reports whether the service is running in a
+	// degraded mode and why. A degraded service is still healthy.
+	DegradedFunc func() (bool, string)
+
 	healthy atomic.Bool
 }
 
@@ -45,6 +52,7 @@ func (s *Server) Healthy() bool {
 func (s *Server) Serve(ctx context.Context) error {
 	m := http.NewServeMux()
 	m.HandleFunc("/healthz", s.handleHealthz)
+	m.Handle("/metrics", promhttp.Handler())
 
 	srv := &http.Server{
 		Addr:         s.Options.Addr(),
@@ -77,6 +85,13 @@ func (s *Server) Serve(ctx context.Context) error {
 
 func (s *Server) handleHealthz(w http.ResponseWriter, r *http.Request) {
 	if s.Healthy() {
+		if s.DegradedFunc != nil {
+			if degraded, reason := s.DegradedFunc(); degraded {
+				writeResponse(w, http.StatusOK, "Degraded: "+reason)
+				return
+			}
+		}
+
 		writeResponse(w, http.StatusOK, "Healthy")
 		return
 	}
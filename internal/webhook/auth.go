@@ -0,0 +1,161 @@
+package webhook
+
+import (
+	"crypto/subtle"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/contaimlabs/external-dns-bunny-webhook/internal/auth"
+)
+
+// authenticator verifies the credentials of webhook requests. Requests are
+// authenticated with a bearer token, a client certificate, or both.
+type authenticator struct {
+	token             string
+	requireClientCert bool
+	allowedNames      []string
+}
+
+// newAuthenticator returns the authenticator configured by the options, or
+// nil if authentication is disabled.
+func newAuthenticator(opts Options) (*authenticator, error) {
+	token, err := auth.Token(opts.AuthToken, opts.AuthTokenFile)
+	if err != nil {
+		return nil, err
+	}
+
+	a := &authenticator{
+		token:             token,
+		requireClientCert: opts.TLSClientCAFile != "",
+		allowedNames:      opts.TLSClientAllowedNames,
+	}
+
+	if a.token == "" && !a.requireClientCert {
+		return nil, nil
+	}
+
+	return a, nil
+}
+
+// tlsConfig returns the TLS configuration of the webhook server, or nil if
+// TLS is disabled.
+func tlsConfig(opts Options) (*tls.Config, error) {
+	if opts.TLSCertFile == "" && opts.TLSKeyFile == "" {
+		if opts.TLSClientCAFile != "" {
+			return nil, fmt.Errorf("client certificates require a TLS certificate and key")
+		}
+
+		return nil, nil
+	}
+
+	cert, err := tls.LoadX509KeyPair(opts.TLSCertFile, opts.TLSKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load TLS certificate: %w", err)
+	}
+
+	config := &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if opts.TLSClientCAFile != "" {
+		data, err := os.ReadFile(opts.TLSClientCAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read client CA file: %w", err)
+		}
+
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(data) {
+			return nil, fmt.Errorf("no certificates found in client CA file %q", opts.TLSClientCAFile)
+		}
+
+		// Certificates are verified during the handshake, but requests
+		// without one are rejected by the authenticator, so clients get a
+		// proper response instead of a failed handshake.
+		config.ClientCAs = pool
+		config.ClientAuth = tls.VerifyClientCertIfGiven
+	}
+
+	return config, nil
+}
+
+// wrap rejects requests that are not authenticated with 401 and requests
+// with a client certificate that is not allowed with 403.
+func (a *authenticator) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if a.requireClientCert {
+			if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 {
+				a.reject(w, r, http.StatusUnauthorized, "client certificate required")
+				return
+			}
+
+			if !a.allowedCertificate(r.TLS.VerifiedChains[0][0]) {
+				a.reject(w, r, http.StatusForbidden, "client certificate not allowed")
+				return
+			}
+		}
+
+		if a.token != "" {
+			scheme, token, _ := strings.Cut(r.Header.Get("Authorization"), " ")
+			if !strings.EqualFold(scheme, "Bearer") || token == "" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="webhook"`)
+				a.reject(w, r, http.StatusUnauthorized, "bearer token required")
+				return
+			}
+
+			if subtle.ConstantTimeCompare([]byte(token), []byte(a.token)) != 1 {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="webhook", error="invalid_token"`)
+				a.reject(w, r, http.StatusUnauthorized, "invalid bearer token")
+				return
+			}
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// allowedCertificate reports whether the client certificate is allowed. Any
+// verified certificate is allowed if no names are configured, otherwise its
+// common name or one of its DNS names has to be listed.
+func (a *authenticator) allowedCertificate(cert *x509.Certificate) bool {
+	if len(a.allowedNames) == 0 {
+		return true
+	}
+
+	if slices.Contains(a.allowedNames, cert.Subject.CommonName) {
+		return true
+	}
+
+	for _, name := range cert.DNSNames {
+		if slices.Contains(a.allowedNames, name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (a *authenticator) reject(w http.ResponseWriter, r *http.Request, status int, message string) {
+	slog.Warn("Rejected unauthenticated webhook request.",
+		slog.String("remote_addr", r.RemoteAddr),
+		slog.String("method", r.Method),
+		slog.String("path", r.URL.Path),
+		slog.Int("status", status),
+		slog.String("reason", message))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	//nolint:errcheck // The status has already been written, nothing to do on failure.
+	json.NewEncoder(w).Encode(map[string]any{
+		"status": status,
+		"error":  message,
+	})
+}
@@ -0,0 +1,207 @@
+package webhook
+
+import (
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// compress negotiates gzip compression of responses larger than minSize and
+// decompresses gzip request bodies. Request bodies are limited to
+// maxRequestSize bytes after decompression. The metrics of the handler are
+// labelled with the given name.
+func compress(name string, next http.Handler, minSize int, maxRequestSize int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.EqualFold(r.Header.Get("Content-Encoding"), "gzip") {
+			body, err := gzip.NewReader(r.Body)
+			if err != nil {
+				slog.Warn("Failed to decompress request body.",
+					slog.String("handler", name),
+					slog.Any("error", err))
+
+				http.Error(w, "invalid gzip request body", http.StatusBadRequest)
+				return
+			}
+
+			defer body.Close()
+
+			r.Body = body
+			r.ContentLength = -1
+			r.Header.Del("Content-Encoding")
+			r.Header.Del("Content-Length")
+		}
+
+		if maxRequestSize > 0 {
+			r.Body = http.MaxBytesReader(w, r.Body, maxRequestSize)
+		}
+
+		w.Header().Add("Vary", "Accept-Encoding")
+
+		if !acceptsGzip(r.Header.Get("Accept-Encoding")) {
+			counting := &countingWriter{w: w}
+			next.ServeHTTP(&countingResponseWriter{ResponseWriter: w, counter: counting}, r)
+			responseBytes.WithLabelValues(name, "identity").Add(float64(counting.n))
+
+			return
+		}
+
+		cw := &compressWriter{ResponseWriter: w, minSize: minSize}
+		next.ServeHTTP(cw, r)
+
+		if err := cw.finish(); err != nil {
+			slog.Warn("Failed to write compressed response.",
+				slog.String("handler", name),
+				slog.Any("error", err))
+		}
+
+		if cw.gz == nil {
+			responseBytes.WithLabelValues(name, "identity").Add(float64(cw.size))
+			return
+		}
+
+		responseBytes.WithLabelValues(name, "gzip").Add(float64(cw.compressed.n))
+
+		if cw.size > 0 {
+			compressionRatio.WithLabelValues(name).Observe(float64(cw.compressed.n) / float64(cw.size))
+		}
+	})
+}
+
+// acceptsGzip reports whether the Accept-Encoding header allows gzip.
+func acceptsGzip(header string) bool {
+	for _, part := range strings.Split(header, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		coding = strings.TrimSpace(coding)
+
+		if !strings.EqualFold(coding, "gzip") && coding != "*" {
+			continue
+		}
+
+		// An explicit quality of zero rules the coding out.
+		if q, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			if value, err := strconv.ParseFloat(q, 64); err == nil && value == 0 {
+				return false
+			}
+		}
+
+		return true
+	}
+
+	return false
+}
+
+// compressWriter buffers a response until it reaches the minimum size and
+// compresses it from then on. Smaller responses are written uncompressed.
+type compressWriter struct {
+	http.ResponseWriter
+
+	minSize     int
+	status      int
+	buf         []byte
+	size        int
+	passthrough bool
+
+	gz         *gzip.Writer
+	compressed *countingWriter
+}
+
+func (w *compressWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *compressWriter) Write(p []byte) (int, error) {
+	w.size += len(p)
+
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(p)
+	case w.passthrough:
+		return w.ResponseWriter.Write(p)
+	}
+
+	w.buf = append(w.buf, p...)
+	if len(w.buf) < w.minSize {
+		return len(p), nil
+	}
+
+	// Responses that are already encoded are passed through as is.
+	if w.Header().Get("Content-Encoding") != "" {
+		return len(p), w.flushUncompressed()
+	}
+
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.statusCode())
+
+	w.compressed = &countingWriter{w: w.ResponseWriter}
+	w.gz = gzip.NewWriter(w.compressed)
+
+	buf := w.buf
+	w.buf = nil
+
+	if _, err := w.gz.Write(buf); err != nil {
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+// finish writes what is left of the response.
+func (w *compressWriter) finish() error {
+	switch {
+	case w.gz != nil:
+		return w.gz.Close()
+	case w.passthrough:
+		return nil
+	}
+
+	return w.flushUncompressed()
+}
+
+// flushUncompressed writes the buffered response as is and passes the rest
+// of the response through.
+func (w *compressWriter) flushUncompressed() error {
+	w.passthrough = true
+	w.ResponseWriter.WriteHeader(w.statusCode())
+
+	_, err := w.ResponseWriter.Write(w.buf)
+	w.buf = nil
+
+	return err
+}
+
+func (w *compressWriter) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+
+	return w.status
+}
+
+// countingWriter counts the bytes written through it.
+type countingWriter struct {
+	w io.Writer
+	n int
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += n
+
+	return n, err
+}
+
+// countingResponseWriter counts the bytes of an uncompressed response.
+type countingResponseWriter struct {
+	http.ResponseWriter
+	counter *countingWriter
+}
+
+func (w *countingResponseWriter) Write(p []byte) (int, error) {
+	return w.counter.Write(p)
+}
@@ -0,0 +1,46 @@
+package webhook
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	requests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "webhook_requests_total",
+		Help:      "Number of webhook requests, by handler, method and status code.",
+	}, []string{"handler", "method", "code"})
+
+	requestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "webhook_request_duration_seconds",
+		Help:      "Latency of webhook requests, by handler and method.",
+		Buckets:   []float64{0.01, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"handler", "method"})
+
+	compressionRatio = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "webhook_response_compression_ratio",
+		Help:      "Size of compressed webhook responses relative to their uncompressed size.",
+		Buckets:   []float64{0.05, 0.1, 0.2, 0.3, 0.4, 0.5, 0.75, 1},
+	}, []string{"handler"})
+
+	responseBytes = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "external_dns_bunny",
+		Name:      "webhook_response_bytes_total",
+		Help:      "Number of webhook response body bytes written, by handler and content encoding.",
+	}, []string{"handler", "encoding"})
+)
+
+// instrument records the count and latency of the requests served by the
+// given handler.
+func instrument(name string, handler http.Handler) http.Handler {
+	labels := prometheus.Labels{"handler": name}
+
+	return promhttp.InstrumentHandlerDuration(requestDuration.MustCurryWith(labels),
+		promhttp.InstrumentHandlerCounter(requests.MustCurryWith(labels), handler))
+}
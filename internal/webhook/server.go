@@ -17,6 +17,16 @@ type Options struct {
 	Port         string        `env:"PORT, default=8888"`
 	ReadTimeout  time.Duration `env:"READ_TIMEOUT, default=60s"`
 	WriteTimeout time.Duration `env:"WRITE_TIMEOUT, default=60s"`
+
+	CompressionMinSize int   `env:"COMPRESSION_MIN_SIZE, default=1024"`
+	MaxRequestSize     int64 `env:"MAX_REQUEST_SIZE, default=10485760"`
+
+	AuthToken             string   `env:"AUTH_TOKEN"`
+	AuthTokenFile         string   `env:"AUTH_TOKEN_FILE"`
+	TLSCertFile           string   `env:"TLS_CERT_FILE"`
+	TLSKeyFile            string   `env:"TLS_KEY_FILE"`
+	TLSClientCAFile       string   `env:"TLS_CLIENT_CA_FILE"`
+	TLSClientAllowedNames []string `env:"TLS_CLIENT_ALLOWED_NAMES"`
 }
 
 func (o *Options) Addr() string {
@@ -39,13 +49,30 @@ func (s *Server) Serve(ctx context.Context) error {
 	}
 
 	m := http.NewServeMux()
-	m.HandleFunc("/", p.NegotiateHandler)
-	m.HandleFunc("/records", p.RecordsHandler)
-	m.HandleFunc("/adjustendpoints", p.AdjustEndpointsHandler)
+	m.Handle("/", s.route("negotiate", p.NegotiateHandler))
+	m.Handle("/records", s.route("records", p.RecordsHandler))
+	m.Handle("/adjustendpoints", s.route("adjustendpoints", p.AdjustEndpointsHandler))
+
+	var handler http.Handler = m
+
+	auth, err := newAuthenticator(s.Options)
+	if err != nil {
+		return fmt.Errorf("invalid authentication options: %w", err)
+	}
+
+	if auth != nil {
+		handler = auth.wrap(handler)
+	}
+
+	tlsConfig, err := tlsConfig(s.Options)
+	if err != nil {
+		return fmt.Errorf("invalid TLS options: %w", err)
+	}
 
 	srv := &http.Server{
 		Addr:         s.Options.Addr(),
-		Handler:      m,
+		Handler:      handler,
+		TLSConfig:    tlsConfig,
 		ReadTimeout:  s.Options.ReadTimeout,
 		WriteTimeout: s.Options.WriteTimeout,
 	}
@@ -69,13 +96,25 @@ func (s *Server) Serve(ctx context.Context) error {
 
 	s.setHealthy(true)
 
-	if err := srv.Serve(l); err != nil {
+	if tlsConfig != nil {
+		err = srv.ServeTLS(l, "", "")
+	} else {
+		err = srv.Serve(l)
+	}
+
+	if err != nil {
 		log.Fatal(err)
 	}
 
 	return nil
 }
 
+// route returns the handler serving the named route with compression and
+// metrics.
+func (s *Server) route(name string, handler http.HandlerFunc) http.Handler {
+	return instrument(name, compress(name, handler, s.Options.CompressionMinSize, s.Options.MaxRequestSize))
+}
+
 func (s *Server) setHealthy(healthy bool) {
 	if s.HealthyFunc == nil {
 		return